@@ -0,0 +1,94 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"unicode"
+)
+
+// Syllable is the structure of a syllable, e.g. "người" has the onset "ng", the vowel "ươi" with
+// the tone on its second rune and no coda. The parts are lowercase and without the tone.
+type Syllable struct {
+	Onset     string // the first consonant, "gi" and "qu" included
+	Vowel     string
+	Coda      string // the last consonant
+	Tone      Tone
+	ToneIndex int    // the rune of Vowel with the tone, or -1
+	Marks     []Mark // the mark on every rune of Onset, Vowel and Coda
+}
+
+// Analyze splits a syllable into its structure the way the engine does with the words it composes.
+// It returns false if the word is not a single syllable, e.g. "Việt Nam" or "hello". The syllable is
+// not checked by the spelling rules, see Diagnose for that.
+func Analyze(word string) (Syllable, bool) {
+	var composition []*Transformation
+	for _, c := range word {
+		composition = append(composition, decomposeChar(c)...)
+	}
+	var previous, last = extractLastSyllable(composition, DefaultSpelling)
+	var syllable = analyze(last)
+	return syllable, len(previous) == 0 && syllable.Vowel != ""
+}
+
+// decomposeChar composes a character like an input method does, with an appending transformation
+// for the bare letter and the mark and tone on it
+func decomposeChar(c rune) []*Transformation {
+	var lower = unicode.ToLower(c)
+	var tone = FindToneFromChar(lower)
+	var mark, _ = FindMarkFromChar(AddToneToChar(lower, 0))
+	var base = AddMarkToChar(AddToneToChar(lower, 0), 0)
+	var appendingTrans = newAppendingTrans(base, unicode.IsUpper(c))
+	var composition = []*Transformation{appendingTrans}
+	if mark != MarkNone {
+		composition = append(composition, &Transformation{
+			Rule: Rule{
+				EffectType: MarkTransformation,
+				Effect:     uint8(mark),
+				EffectOn:   base,
+				Result:     AddMarkToChar(base, uint8(mark)),
+			},
+			Target: appendingTrans,
+		})
+	}
+	if tone != ToneNone {
+		composition = append(composition, &Transformation{
+			Rule:   Rule{EffectType: ToneTransformation, Effect: uint8(tone)},
+			Target: appendingTrans,
+		})
+	}
+	return composition
+}
+
+// Analyze returns the structure of the syllable being typed, e.g. "nam" in "vieetnam"
+func (e *BambooEngine) Analyze() Syllable {
+	var _, last = extractLastSyllable(e.composition, e.spelling())
+	return analyze(last)
+}
+
+func analyze(composition []*Transformation) Syllable {
+	var fc, vo, lc = extractCvcTrans(composition)
+	var syllable = Syllable{ToneIndex: -1}
+	var parts = []*string{&syllable.Onset, &syllable.Vowel, &syllable.Coda}
+	for i, part := range [][]*Transformation{fc, vo, lc} {
+		var runes = []rune(Flatten(part, VietnameseMode|LowerCase))
+		for j, c := range runes {
+			if tone := FindToneFromChar(c); tone != ToneNone {
+				syllable.Tone = tone
+				if parts[i] == &syllable.Vowel {
+					syllable.ToneIndex = j
+				}
+				runes[j] = AddToneToChar(c, 0)
+			}
+			var mark, _ = FindMarkFromChar(runes[j])
+			syllable.Marks = append(syllable.Marks, mark)
+		}
+		*parts[i] = string(runes)
+	}
+	return syllable
+}
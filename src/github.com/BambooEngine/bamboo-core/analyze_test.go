@@ -0,0 +1,64 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	var tests = []struct {
+		word     string
+		expected Syllable
+	}{
+		{"Người", Syllable{"ng", "ươi", "", ToneGrave, 1, []Mark{MarkNone, MarkNone, MarkHorn, MarkHorn, MarkNone}}},
+		{"đường", Syllable{"đ", "ươ", "ng", ToneGrave, 1, []Mark{MarkDash, MarkHorn, MarkHorn, MarkNone, MarkNone}}},
+		{"giá", Syllable{"gi", "a", "", ToneAcute, 0, []Mark{MarkNone, MarkNone, MarkNone}}},
+		{"giếng", Syllable{"g", "iê", "ng", ToneAcute, 1, []Mark{MarkNone, MarkNone, MarkHat, MarkNone, MarkNone}}},
+		{"quốc", Syllable{"qu", "ô", "c", ToneAcute, 0, []Mark{MarkNone, MarkNone, MarkHat, MarkNone}}},
+		{"trăng", Syllable{"tr", "ă", "ng", ToneNone, -1, []Mark{MarkNone, MarkNone, MarkBreve, MarkNone, MarkNone}}},
+		{"oà", Syllable{"", "oa", "", ToneGrave, 1, []Mark{MarkNone, MarkNone}}},
+	}
+	for _, test := range tests {
+		if s, ok := Analyze(test.word); !ok || !reflect.DeepEqual(s, test.expected) {
+			t.Errorf("Analyze [%s], got %+v %t expected %+v", test.word, s, ok, test.expected)
+		}
+	}
+}
+
+func TestAnalyzeNotSyllable(t *testing.T) {
+	for _, word := range []string{"", "Việt Nam", "hello", "viêtn", "ngh"} {
+		if s, ok := Analyze(word); ok {
+			t.Errorf("Analyze [%s], got %+v expected not a syllable", word, s)
+		}
+	}
+}
+
+func TestEngineAnalyze(t *testing.T) {
+	var ng = newStdEngine().(*BambooEngine)
+	ng.ProcessString("tieengs Vieetj", VietnameseMode)
+	var expected, _ = Analyze("Việt")
+	if s := ng.Analyze(); !reflect.DeepEqual(s, expected) {
+		t.Errorf("Analyze [tieengs Vieetj], got %+v expected %+v", s, expected)
+	}
+	if expected.Onset != "v" || expected.Vowel != "iê" || expected.Coda != "t" || expected.Tone != ToneDot {
+		t.Errorf("Analyze [Việt], got %+v", expected)
+	}
+	// the syllable being typed, not the whole word
+	ng.Reset()
+	ng.ProcessString("vieetnam", VietnameseMode)
+	if s := ng.Analyze(); s.Onset != "n" || s.Vowel != "a" || s.Coda != "m" {
+		t.Errorf("Analyze [vieetnam], got %+v expected the syllable [nam]", s)
+	}
+	ng.Reset()
+	if s := ng.Analyze(); s.Vowel != "" || s.ToneIndex != -1 {
+		t.Errorf("Analyze an empty engine, got %+v", s)
+	}
+}
@@ -24,12 +24,16 @@ const (
 	FullText
 	PunctuationMode
 	InReverseOrder
+	Decomposed
 )
 
 const (
 	EfreeToneMarking uint = 1 << iota
 	EstdToneStyle
 	EautoCorrectEnabled
+	EquickTelex
+	EquickStartConsonant
+	EquickEndConsonant
 	EstdFlags = EfreeToneMarking | EstdToneStyle | EautoCorrectEnabled
 )
 
@@ -52,10 +56,24 @@ type IEngine interface {
 	Reset()
 }
 
+// ISyllableChecker is implemented by the engines which explain and correct the syllable being typed
+type ISyllableChecker interface {
+	Diagnose(bool) Diagnosis
+	Analyze() Syllable
+	Suggest(int) []string
+}
+
+// ISnapshotter is implemented by the engines whose composition can be saved and given back later
+type ISnapshotter interface {
+	Snapshot() Snapshot
+	Restore(Snapshot) error
+}
+
 type BambooEngine struct {
-	composition []*Transformation
-	inputMethod InputMethod
-	flags       uint
+	composition    []*Transformation
+	inputMethod    InputMethod
+	flags          uint
+	syllableAnchor syllableAnchor
 }
 
 func NewEngine(inputMethod InputMethod, flag uint) IEngine {
@@ -78,6 +96,14 @@ func (e *BambooEngine) GetFlag(flag uint) uint {
 	return e.flags
 }
 
+// spelling returns the spelling rules of the input method, or the Vietnamese ones
+func (e *BambooEngine) spelling() *Spelling {
+	if e.inputMethod.Spelling != nil {
+		return e.inputMethod.Spelling
+	}
+	return DefaultSpelling
+}
+
 func (e *BambooEngine) isSuperKey(lowerKey rune) bool {
 	return inKeyList(e.GetInputMethod().SuperKeys, lowerKey)
 }
@@ -92,7 +118,23 @@ func (e *BambooEngine) isEffectiveKey(key rune) bool {
 
 func (e *BambooEngine) IsValid(inputIsFullComplete bool) bool {
 	var _, last = extractLastWord(e.composition, e.GetInputMethod().Keys)
-	return isValid(last, inputIsFullComplete)
+	return e.spelling().isValid(last, inputIsFullComplete)
+}
+
+// Diagnose tells why the last word is not a valid syllable, if it is not
+func (e *BambooEngine) Diagnose(inputIsFullComplete bool) Diagnosis {
+	var _, last = extractLastWord(e.composition, e.GetInputMethod().Keys)
+	if len(last) <= 1 {
+		return Diagnosis{Verdict: VerdictValid}
+	}
+	return e.spelling().diagnose(last, inputIsFullComplete)
+}
+
+// Suggest returns up to limit valid syllables that are close to the syllable being typed, with the
+// tone placed in the style of the engine
+func (e *BambooEngine) Suggest(limit int) []string {
+	var _, last = extractLastSyllable(e.composition, e.spelling())
+	return e.spelling().Suggest(Flatten(last, VietnameseMode|LowerCase), limit, e.flags&EstdToneStyle != 0)
 }
 
 func (e *BambooEngine) GetProcessedString(mode Mode) string {
@@ -109,6 +151,10 @@ func (e *BambooEngine) GetProcessedString(mode Mode) string {
 }
 
 func (e *BambooEngine) getApplicableRules(key rune) []Rule {
+	if e.inputMethod.rulesByKey != nil {
+		return e.inputMethod.rulesByKey[unicode.ToLower(key)]
+	}
+	// an input method which is not parsed by ParseInputMethod has no index
 	var applicableRules []Rule
 	for _, inputRule := range e.inputMethod.Rules {
 		if inputRule.Key == unicode.ToLower(key) {
@@ -119,7 +165,7 @@ func (e *BambooEngine) getApplicableRules(key rune) []Rule {
 }
 
 func (e *BambooEngine) findTargetByKey(composition []*Transformation, key rune) (*Transformation, Rule) {
-	return findTarget(composition, e.getApplicableRules(key), e.flags)
+	return findTarget(composition, e.getApplicableRules(key), e.flags, e.spelling())
 }
 
 func (e *BambooEngine) CanProcessKey(key rune) bool {
@@ -127,7 +173,11 @@ func (e *BambooEngine) CanProcessKey(key rune) bool {
 }
 
 func (e *BambooEngine) generateTransformations(composition []*Transformation, lowerKey rune, isUpperCase bool) []*Transformation {
-	var transformations = generateTransformations(composition, e.getApplicableRules(lowerKey), e.flags, lowerKey, isUpperCase)
+	var rules = e.getApplicableRules(lowerKey)
+	var transformations = generateQuickTransformations(composition, rules, e.flags, lowerKey, isUpperCase, e.spelling())
+	if transformations == nil {
+		transformations = generateTransformations(composition, rules, e.flags, lowerKey, isUpperCase, e.spelling())
+	}
 	if transformations == nil {
 		// If none of the applicable_rules can actually be applied then this new
 		// transformation fall-backs to an APPENDING one.
@@ -167,7 +217,7 @@ func (e *BambooEngine) applyUowShortcut(syllable []*Transformation) *Transformat
 }
 
 func (e *BambooEngine) refreshLastToneTarget(syllable []*Transformation) []*Transformation {
-	if e.flags&EfreeToneMarking != 0 && isValid(syllable, false) {
+	if e.flags&EfreeToneMarking != 0 && e.spelling().isValid(syllable, false) {
 		return refreshLastToneTarget(syllable, e.flags&EstdToneStyle != 0)
 	}
 	return nil
@@ -182,6 +232,9 @@ func (e *BambooEngine) ProcessString(str string, mode Mode) {
 }
 
 func (e *BambooEngine) ProcessKey(key rune, mode Mode) {
+	if IsCombiningChar(key) && mode&InReverseOrder == 0 && e.composeLastChar(key, mode) {
+		return
+	}
 	var lowerKey = unicode.ToLower(key)
 	var isUpperCase = unicode.IsUpper(key)
 	if mode&EnglishMode != 0 || !e.CanProcessKey(lowerKey) {
@@ -193,13 +246,22 @@ func (e *BambooEngine) ProcessKey(key rune, mode Mode) {
 		return
 	}
 	// Just process the key stroke on the last syllable
-	var previousTransformations, lastSyllable = extractLastSyllable(e.composition)
+	var previousTransformations, lastSyllable = extractLastSyllableFrom(e.composition, e.spelling(), &e.syllableAnchor)
 
 	// Find all possible transformations this keypress can generate
-	lastSyllable = append(lastSyllable, e.generateTransformations(lastSyllable, lowerKey, isUpperCase)...)
+	var transformations = e.generateTransformations(lastSyllable, lowerKey, isUpperCase)
+	lastSyllable = append(lastSyllable, transformations...)
 
 	// Put these transformations back to the composition
 	e.composition = append(previousTransformations, lastSyllable...)
+
+	// a consonant cluster may join the last syllable to the one before it, e.g. òac + c -> òach, the
+	// tone then moves within the joined syllable
+	if len(transformations) > 0 && transformations[0].Rule.EffectType == Replacing && len(previousTransformations) > 0 {
+		if _, syllable := extractLastSyllable(e.composition, e.spelling()); len(syllable) > len(lastSyllable) {
+			e.composition = append(e.composition, e.refreshLastToneTarget(syllable)...)
+		}
+	}
 }
 
 func (e *BambooEngine) RestoreLastWord() {
@@ -226,17 +288,78 @@ func (e *BambooEngine) RemoveLastChar(refreshLastToneTarget bool) {
 		return
 	}
 	var previous, lastComb = extractLastWord(e.composition, e.GetInputMethod().Keys)
-	var newComb []*Transformation
-	for _, t := range lastComb {
-		if t.Target == lastAppending || t == lastAppending {
+	// the word is typed again without the keys of the letter, so its tones and marks are placed as if
+	// the letter had never been typed, e.g. ó + a -> oá -> ó
+	if refreshLastToneTarget && Flatten(e.retype(lastComb, nil), VietnameseMode) == Flatten(lastComb, VietnameseMode) {
+		e.composition = append(previous, e.retype(lastComb, e.findKeysOfLastChar(lastComb, lastAppending))...)
+		return
+	}
+	// the app has removed the letter by itself or the keys do not type the word, e.g. a restored word,
+	// the other letters stay as they are shown
+	e.composition = append(previous, removeLastAppending(lastComb, lastAppending)...)
+}
+
+// findKeysOfLastChar returns the keys that typed the last letter of a word: the letter itself, its
+// tones and marks, and the tone or mark undone by the key of the letter, e.g. o + f + f -> of. Only the
+// key of a quick telex cluster is removed, e.g. ch -> c
+func (e *BambooEngine) findKeysOfLastChar(word []*Transformation, lastAppending *Transformation) map[*Transformation]bool {
+	var keys = map[*Transformation]bool{}
+	if replacing := findLastReplacingTrans(word, lastAppending); replacing != nil && replacing.Rule.Key != 0 {
+		keys[replacing] = true
+		return keys
+	}
+	for i, t := range word {
+		if t.Rule.Key == 0 || t != lastAppending && t.Target != lastAppending {
+			continue
+		}
+		keys[t] = true
+		if t != lastAppending || i == 0 {
+			continue
+		}
+		var undo = word[i-1]
+		if undo.Rule.Key != 0 || undo.Rule.Effect != 0 || undo.Rule.EffectType != ToneTransformation && undo.Rule.EffectType != MarkTransformation {
 			continue
 		}
-		newComb = append(newComb, t)
+		for j := i - 2; j >= 0; j-- {
+			if word[j].Rule.Key != 0 && word[j].Rule.EffectType == undo.Rule.EffectType {
+				// a key which removes the tone has nothing to be undone, e.g. o + f + z + z -> oz
+				keys[word[j]] = word[j].Rule.Effect != 0 && e.canUndo(lastAppending.Rule.Key, word[j])
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// canUndo tells whether the key types the same tone or mark as trans, e.g. f + f or s + 1 (Telex + VNI)
+func (e *BambooEngine) canUndo(key rune, trans *Transformation) bool {
+	for _, rule := range e.getApplicableRules(unicode.ToLower(key)) {
+		if rule.EffectType == trans.Rule.EffectType && rule.Effect == trans.Rule.Effect {
+			return true
+		}
 	}
-	if refreshLastToneTarget {
-		newComb = append(newComb, e.refreshLastToneTarget(newComb)...)
+	return false
+}
+
+// retype types the keys of a word again but the removed ones
+func (e *BambooEngine) retype(word []*Transformation, removed map[*Transformation]bool) []*Transformation {
+	var composition = e.composition
+	e.composition, e.syllableAnchor = nil, syllableAnchor{}
+	for _, t := range word {
+		if t.Rule.Key != 0 && !removed[t] {
+			var key = t.Rule.Key
+			if t.IsUpperCase {
+				key = unicode.ToUpper(key)
+			}
+			e.ProcessKey(key, VietnameseMode)
+		} else if t.Rule.EffectType == MarkTransformation && Mark(t.Rule.Effect) == MarkRaw && !removed[t.Target] {
+			// the key typed twice has no transformation of its own, e.g. w + w -> w (Telex 2)
+			e.ProcessKey(t.Target.Rule.Key, VietnameseMode)
+		}
 	}
-	e.composition = append(previous, newComb...)
+	var typed = e.composition
+	e.composition, e.syllableAnchor = composition, syllableAnchor{}
+	return typed
 }
 
 /***** END SIDE-EFFECT METHODS ******/
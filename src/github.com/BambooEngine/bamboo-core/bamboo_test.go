@@ -10,6 +10,7 @@ package bamboo
 
 import (
 	"log"
+	"strings"
 	"testing"
 )
 
@@ -193,6 +194,28 @@ func TestProcessNguwowfiString(t *testing.T) {
 	}
 }
 
+func TestRemoveLastCharRetypesWord(t *testing.T) {
+	var tests = []struct {
+		keys     string
+		expected string
+		raw      string
+	}{
+		{"off", "o", "o"},
+		{"ooo", "o", "o"},
+		{"buow", "bu", "bu"},
+		{"ifzz", "i", "ifz"},
+		{"osxa", "õ", "osx"},
+	}
+	for _, test := range tests {
+		ng := newStdEngine()
+		ng.ProcessString(test.keys, VietnameseMode)
+		ng.RemoveLastChar(true)
+		if s, raw := ng.GetProcessedString(VietnameseMode), ng.GetProcessedString(EnglishMode); s != test.expected || raw != test.raw {
+			t.Errorf("Process %s-1, got [%s] [%s] expected [%s] [%s]", test.keys, s, raw, test.expected, test.raw)
+		}
+	}
+}
+
 func TestRemoveLastChar(t *testing.T) {
 	ng := newStdEngine()
 	ng.ProcessString("hanhj", VietnameseMode)
@@ -306,6 +329,22 @@ func TestProcessRefresh2(t *testing.T) {
 	}
 }
 
+func TestProcessRefreshOldToneTarget(t *testing.T) {
+	ng := NewEngine(ParseInputMethod(InputMethodDefinitions, "Telex"), EstdFlags)
+	ng.ProcessString("pojSao", VietnameseMode)
+	if ng.GetProcessedString(VietnameseMode) != "poáo" {
+		t.Errorf("Process pojSao, got [%v] expected [poáo]", ng.GetProcessedString(VietnameseMode))
+	}
+}
+
+func TestProcessQuickTelexJoinedSyllable(t *testing.T) {
+	ng := NewEngine(ParseInputMethod(InputMethodDefinitions, "Telex + VNI + VIQR"), EstdFlags|EquickTelex)
+	ng.ProcessString("oa2ccx", VietnameseMode)
+	if ng.GetProcessedString(VietnameseMode) != "oãch" {
+		t.Errorf("Process oa2ccx, got [%v] expected [oãch]", ng.GetProcessedString(VietnameseMode))
+	}
+}
+
 func TestProcessDDSeq(t *testing.T) {
 	ng := newStdEngine()
 	ng.ProcessString("oddp", VietnameseMode)
@@ -660,3 +699,52 @@ func BenchmarkRemoveLastChar(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkProcessKey(b *testing.B) {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex + VNI + VIQR")
+	var ng = NewEngine(im, EstdFlags)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ng.Reset()
+		ng.ProcessString("nguwowif", VietnameseMode)
+	}
+}
+
+func BenchmarkProcessLongBuffer(b *testing.B) {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex + VNI + VIQR")
+	var ng = NewEngine(im, EstdFlags)
+	var text = strings.Repeat("trawm nawm trong coxi nguowfi ta, chuwx taif chuwx meejnh kheso laf ghest nhau. ", 10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ng.Reset()
+		ng.ProcessString(text, VietnameseMode)
+	}
+}
+
+func BenchmarkProcessLongWord(b *testing.B) {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex + VNI + VIQR")
+	var ng = NewEngine(im, EstdFlags)
+	var text = strings.Repeat("httpwwwexamplecom", 10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ng.Reset()
+		ng.ProcessString(text, VietnameseMode)
+	}
+}
+
+func BenchmarkGetApplicableRules(b *testing.B) {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex + VNI + VIQR")
+	var indexed = &BambooEngine{inputMethod: im}
+	im.rulesByKey = nil
+	var scanned = &BambooEngine{inputMethod: im}
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			indexed.getApplicableRules('w')
+		}
+	})
+	b.Run("scanned", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanned.getApplicableRules('w')
+		}
+	})
+}
@@ -23,6 +23,28 @@ func findLastAppendingTrans(composition []*Transformation) *Transformation {
 	return nil
 }
 
+// removeLastAppending removes lastAppending and its effects from a word, the other transformations are
+// kept as they are. A consonant cluster of quick telex is undone first, e.g. ch -> c
+func removeLastAppending(word []*Transformation, lastAppending *Transformation) []*Transformation {
+	var replacing = findLastReplacingTrans(word, lastAppending)
+	var undoReplacing = replacing != nil && replacing.Rule.Key != 0
+	var newComb []*Transformation
+	for i, t := range word {
+		if undoReplacing {
+			if t == replacing {
+				continue
+			}
+		} else if t.Target == lastAppending || t == lastAppending {
+			continue
+		} else if i+1 < len(word) && word[i+1] == lastAppending && isUndoingTrans(t, word[:i]) {
+			// the key of lastAppending also undid a cluster, e.g. ch + c -> cc
+			continue
+		}
+		newComb = append(newComb, t)
+	}
+	return newComb
+}
+
 func newAppendingTrans(key rune, isUpperCase bool) *Transformation {
 	return &Transformation{
 		IsUpperCase: isUpperCase,
@@ -68,24 +90,35 @@ func findRootTarget(target *Transformation) *Transformation {
 	}
 }
 
-func isValid(composition []*Transformation, inputIsFullComplete bool) bool {
+func (s *Spelling) isValid(composition []*Transformation, inputIsFullComplete bool) bool {
 	if len(composition) <= 1 {
 		return true
 	}
+	return s.diagnose(composition, inputIsFullComplete).Verdict == VerdictValid
+}
+
+func (s *Spelling) diagnose(composition []*Transformation, inputIsFullComplete bool) Diagnosis {
+	var fc, vo, lc = extractCvcTrans(composition)
+	var flattenMode = VietnameseMode | LowerCase | ToneLess
+	var d = Diagnosis{
+		Onset: Flatten(fc, flattenMode),
+		Vowel: Flatten(vo, flattenMode),
+		Coda:  Flatten(lc, flattenMode),
+	}
 	// last tone checking
 	for i := len(composition) - 1; i >= 0; i-- {
 		if composition[i].Rule.EffectType == ToneTransformation {
-			var lastTone = Tone(composition[i].Rule.Effect)
-			if !hasValidTone(composition, lastTone) {
-				return false
+			d.Tone = Tone(composition[i].Rule.Effect)
+			if !s.hasValidTone(composition, d.Tone) {
+				d.Verdict = VerdictInvalidTone
+				return d
 			}
 			break
 		}
 	}
 	// spell checking
-	var fc, vo, lc = extractCvcTrans(composition)
-	var flattenMode = VietnameseMode | LowerCase | ToneLess
-	return isValidCVC(Flatten(fc, flattenMode), Flatten(vo, flattenMode), Flatten(lc, flattenMode), inputIsFullComplete)
+	d.Verdict = s.diagnoseCVC(d.Onset, d.Vowel, d.Coda, inputIsFullComplete)
+	return d
 }
 
 func getRightMostVowels(composition []*Transformation) []*Transformation {
@@ -123,7 +156,8 @@ func findToneTarget(composition []*Transformation, stdStyle bool) *Transformatio
 		if len(lc) > 0 {
 			target = vowels[1]
 		} else {
-			var str = Flatten(vowels, EnglishMode|LowerCase|ToneLess|MarkLess)
+			// the vowels typed directly, e.g. ê with 3 in the Microsoft layout, are read by their letters
+			var str = Flatten(vowels, VietnameseMode|LowerCase|ToneLess|MarkLess)
 			if str == "oa" || str == "oe" || str == "uy" || str == "ue" || str == "uo" {
 				target = vowels[1]
 			} else {
@@ -131,7 +165,7 @@ func findToneTarget(composition []*Transformation, stdStyle bool) *Transformatio
 			}
 		}
 	} else if len(vowels) == 3 {
-		if Flatten(vowels, EnglishMode|LowerCase|ToneLess|MarkLess) == "uye" {
+		if Flatten(vowels, VietnameseMode|LowerCase|ToneLess|MarkLess) == "uye" {
 			target = vowels[2]
 		} else {
 			target = vowels[1]
@@ -140,7 +174,7 @@ func findToneTarget(composition []*Transformation, stdStyle bool) *Transformatio
 	return target
 }
 
-func hasValidTone(composition []*Transformation, tone Tone) bool {
+func (s *Spelling) hasValidTone(composition []*Transformation, tone Tone) bool {
 	if tone == ToneNone || tone == ToneAcute || tone == ToneDot {
 		return true
 	}
@@ -151,9 +185,8 @@ func hasValidTone(composition []*Transformation, tone Tone) bool {
 	var lastConsonants = Flatten(lc, EnglishMode|LowerCase)
 
 	// These consonants have to go with ACUTE, DOT accents
-	var dotWithConsonants = []string{"c", "k", "p", "t", "ch"}
-	for _, s := range dotWithConsonants {
-		if s == lastConsonants {
+	for _, c := range s.stopConsonants {
+		if c == lastConsonants {
 			return false
 		}
 	}
@@ -242,54 +275,83 @@ func extractCvcTrans(composition []*Transformation) ([]*Transformation, []*Trans
 }
 
 func extractLastPunctuationMarks(composition []*Transformation, effectKeys []rune) ([]*Transformation, []*Transformation) {
-	for i := len(composition) - 1; i >= 0; i-- {
-		var canvas = getCanvas(composition[i:], VietnameseMode|LowerCase|ToneLess|MarkLess)
-		if len(canvas) == 0 {
-			continue
-		}
-		var c = canvas[0]
-		if IsAlpha(c) || inKeyList(effectKeys, c) {
-			if i == len(composition)-1 {
-				return composition, nil
-			}
-			return composition[:i+1], composition[i+1:]
-		}
-	}
-	return nil, composition
+	return splitComposition(composition, findLastCharIndex(composition, VietnameseMode|LowerCase|ToneLess|MarkLess, func(c rune) bool {
+		return IsAlpha(c) || inKeyList(effectKeys, c)
+	}))
 }
 
 func extractLastWord(composition []*Transformation, effectKeys []rune) ([]*Transformation, []*Transformation) {
-	for i := len(composition) - 1; i >= 0; i-- {
-		var canvas = getCanvas(composition[i:], VietnameseMode|LowerCase|ToneLess|MarkLess)
-		if len(canvas) == 0 {
-			continue
-		}
-		var c = canvas[0]
-		if !IsAlpha(c) && !inKeyList(effectKeys, c) {
-			if i == len(composition)-1 {
-				return composition, nil
-			}
-			return composition[:i+1], composition[i+1:]
+	return splitComposition(composition, findLastCharIndex(composition, VietnameseMode|LowerCase|ToneLess|MarkLess, func(c rune) bool {
+		return !IsAlpha(c) && !inKeyList(effectKeys, c)
+	}))
+}
+
+// splitComposition splits the composition after the transformation at index i
+func splitComposition(composition []*Transformation, i int) ([]*Transformation, []*Transformation) {
+	if i < 0 {
+		return nil, composition
+	}
+	if i == len(composition)-1 {
+		return composition, nil
+	}
+	return composition[:i+1], composition[i+1:]
+}
+
+// syllableAnchor remembers where the last syllable of a word starts, so the syllables of a long word
+// are not checked again from its beginning on every key stroke
+type syllableAnchor struct {
+	word   []*Transformation // the beginning of the word that has been checked
+	anchor int
+}
+
+// resumes tells if the word goes on from the checked beginning
+func (a *syllableAnchor) resumes(word []*Transformation) bool {
+	if len(a.word) == 0 || len(a.word) >= len(word) {
+		return false
+	}
+	for i, trans := range a.word {
+		if word[i] != trans {
+			return false
 		}
 	}
-	return nil, composition
+	return true
+}
+
+func extractLastSyllable(composition []*Transformation, spelling *Spelling) ([]*Transformation, []*Transformation) {
+	return extractLastSyllableFrom(composition, spelling, nil)
 }
 
-func extractLastSyllable(composition []*Transformation) ([]*Transformation, []*Transformation) {
+func extractLastSyllableFrom(composition []*Transformation, spelling *Spelling, cache *syllableAnchor) ([]*Transformation, []*Transformation) {
 	var previous, last = extractLastWord(composition, nil)
-	var anchor = 0
-	for i := range last {
-		if !isValid(last[anchor:i+1], false) {
+	var anchor, start = 0, 0
+	if cache != nil && cache.resumes(last) {
+		anchor, start = cache.anchor, len(cache.word)
+	}
+	var lastAnchor = anchor
+	for i := start; i < len(last); i++ {
+		if i == len(last)-1 {
+			// the last transformation is checked again with the next key, which may replace it
+			lastAnchor = anchor
+		}
+		if i+1 < len(last) && last[i+1].Rule.EffectType == Replacing {
+			// a consonant cluster is checked as a whole
+			continue
+		}
+		if !spelling.isValid(last[anchor:i+1], false) {
 			anchor = i
 		}
 	}
+	if cache != nil && len(last) > 0 {
+		cache.word = append(cache.word[:0], last[:len(last)-1]...)
+		cache.anchor = lastAnchor
+	}
 	if anchor > 0 {
 		previous = append(previous, last[:anchor]...)
 	}
 	return previous, last[anchor:]
 }
 
-func findMarkTarget(composition []*Transformation, rules []Rule) (*Transformation, Rule) {
+func findMarkTarget(composition []*Transformation, rules []Rule, spelling *Spelling) (*Transformation, Rule) {
 	var str = Flatten(composition, VietnameseMode)
 	for i := len(composition) - 1; i >= 0; i-- {
 		var trans = composition[i]
@@ -303,7 +365,7 @@ func findMarkTarget(composition []*Transformation, rules []Rule) (*Transformatio
 					continue
 				}
 				var tmp = append(composition, &Transformation{Rule: rule, Target: target})
-				if isValid(tmp, false) {
+				if spelling.isValid(tmp, false) {
 					return target, rule
 				}
 			}
@@ -312,7 +374,7 @@ func findMarkTarget(composition []*Transformation, rules []Rule) (*Transformatio
 	return nil, Rule{}
 }
 
-func findTarget(composition []*Transformation, applicableRules []Rule, flags uint) (*Transformation, Rule) {
+func findTarget(composition []*Transformation, applicableRules []Rule, flags uint, spelling *Spelling) (*Transformation, Rule) {
 	var str = Flatten(composition, VietnameseMode)
 	// find tone target
 	for _, applicableRule := range applicableRules {
@@ -321,7 +383,7 @@ func findTarget(composition []*Transformation, applicableRules []Rule, flags uin
 		}
 		var target *Transformation
 		if flags&EfreeToneMarking != 0 {
-			if hasValidTone(composition, Tone(applicableRule.Effect)) {
+			if spelling.hasValidTone(composition, Tone(applicableRule.Effect)) {
 				target = findToneTarget(composition, flags&EstdToneStyle != 0)
 			}
 		} else if lastAppending := findLastAppendingTrans(composition); lastAppending != nil && IsVowel(lastAppending.Rule.EffectOn) {
@@ -336,17 +398,17 @@ func findTarget(composition []*Transformation, applicableRules []Rule, flags uin
 		}
 		return target, applicableRule
 	}
-	return findMarkTarget(composition, applicableRules)
+	return findMarkTarget(composition, applicableRules, spelling)
 }
 
-func generateUndoTransformations(composition []*Transformation, rules []Rule, flags uint) []*Transformation {
+func generateUndoTransformations(composition []*Transformation, rules []Rule, flags uint, spelling *Spelling) []*Transformation {
 	var transformations []*Transformation
 	var str = Flatten(composition, VietnameseMode|ToneLess|LowerCase)
 	for _, rule := range rules {
 		if rule.EffectType == ToneTransformation {
 			var target *Transformation
 			if flags&EfreeToneMarking != 0 {
-				if hasValidTone(composition, Tone(rule.Effect)) {
+				if spelling.hasValidTone(composition, Tone(rule.Effect)) {
 					target = findToneTarget(composition, flags&EstdToneStyle != 0)
 				}
 			} else if lastAppending := findLastAppendingTrans(composition); lastAppending != nil && IsVowel(lastAppending.Rule.EffectOn) {
@@ -399,7 +461,7 @@ var regUhO = regexp.MustCompile(`(ưo|ươ)`)
 * 7 | (u)wo + w  ->  undo + append       -> uow
 * ...
 **/
-func generateTransformations(composition []*Transformation, applicableRules []Rule, flags uint, lowerKey rune, isUpperCase bool) []*Transformation {
+func generateTransformations(composition []*Transformation, applicableRules []Rule, flags uint, lowerKey rune, isUpperCase bool, spelling *Spelling) []*Transformation {
 	var transformations []*Transformation
 	// Double typing an effect key undoes it and its effects, e.g. w + w -> w (Telex 2)
 	if len(composition) > 0 {
@@ -417,7 +479,7 @@ func generateTransformations(composition []*Transformation, applicableRules []Ru
 		}
 	}
 	// A target may be applied by many different transformations, e.g. o + o + w -> ơ
-	if target, applicableRule := findTarget(composition, applicableRules, flags); target != nil {
+	if target, applicableRule := findTarget(composition, applicableRules, flags, spelling); target != nil {
 		transformations = append(transformations, &Transformation{
 			Rule:        applicableRule,
 			Target:      target,
@@ -427,12 +489,12 @@ func generateTransformations(composition []*Transformation, applicableRules []Ru
 			return transformations
 		}
 		var newComp = append(composition, transformations...)
-		if isValid(newComp, true) {
+		if spelling.isValid(newComp, true) {
 			return transformations
 		}
 		// Implement the uow typing shortcut by creating a virtual
 		// Mark_HORN rule that targets 'u' or 'o'.
-		if target, virtualRule := findTarget(newComp, applicableRules, flags); target != nil {
+		if target, virtualRule := findTarget(newComp, applicableRules, flags, spelling); target != nil {
 			virtualRule.Key = 0
 			return append(transformations, &Transformation{virtualRule, target, false})
 		}
@@ -448,7 +510,7 @@ func generateTransformations(composition []*Transformation, applicableRules []Ru
 					Effect:     uint8(MarkNone),
 				},
 			}
-			if target, applicableRule := findTarget(append(composition, trans), applicableRules, flags); target != nil && target != vowels[0] {
+			if target, applicableRule := findTarget(append(composition, trans), applicableRules, flags, spelling); target != nil && target != vowels[0] {
 				transformations = append(transformations, trans)
 				transformations = append(transformations, &Transformation{
 					Rule:        applicableRule,
@@ -458,7 +520,7 @@ func generateTransformations(composition []*Transformation, applicableRules []Ru
 				return transformations
 			}
 		}
-		if undoTrans := generateUndoTransformations(composition, applicableRules, flags); len(undoTrans) > 0 {
+		if undoTrans := generateUndoTransformations(composition, applicableRules, flags, spelling); len(undoTrans) > 0 {
 			// If an effect key can't find its target, it tries to undo its effects, e.g. ươ + w -> uow
 			transformations = append(transformations, undoTrans...)
 			transformations = append(transformations, newAppendingTrans(lowerKey, isUpperCase))
@@ -504,7 +566,7 @@ func refreshLastToneTarget(composition []*Transformation, stdStyle bool) []*Tran
 	}
 	var newToneTarget = findToneTarget(composition, stdStyle)
 	if lastToneTrans.Target != newToneTarget {
-		lastToneTrans.Target = newToneTarget
+		// the old target loses every tone, the one typed before the last tone too, e.g. ọ + s -> ó + a -> oá
 		transformations = append(transformations, &Transformation{
 			Target: lastToneTrans.Target,
 			Rule: Rule{
@@ -10,10 +10,16 @@ package bamboo
 
 const UNICODE = "Unicode"
 
+// UNICODE_NFD is the decomposed form of Unicode, the marks and the tones are combining characters
+const UNICODE_NFD = "Unicode NFD"
+
 func Encode(charsetName string, input string) string {
 	if charsetName == UNICODE {
 		return input
 	}
+	if charsetName == UNICODE_NFD {
+		return DecomposeNFD(input)
+	}
 	var output string
 	if charset, found := charsetDefinitions[charsetName]; found {
 		for _, chr := range input {
@@ -31,7 +37,7 @@ func Encode(charsetName string, input string) string {
 
 func GetCharsetNames() []string {
 	var names []string
-	names = append(names, UNICODE)
+	names = append(names, UNICODE, UNICODE_NFD)
 	for cs := range charsetDefinitions {
 		names = append(names, cs)
 	}
@@ -13,11 +13,31 @@ import (
 )
 
 func Flatten(composition []*Transformation, mode Mode) string {
+	if mode&Decomposed != 0 {
+		return DecomposeNFD(string(getCanvas(composition, mode)))
+	}
 	return string(getCanvas(composition, mode))
 }
 
 func getCanvas(composition []*Transformation, mode Mode) []rune {
 	var canvas []rune
+	var appendingList, appendingMap = getAppendingMap(composition, mode)
+	for _, appendingTrans := range appendingList {
+		var chr, replacing = flattenChar(appendingTrans, appendingMap[appendingTrans], mode)
+		canvas = append(canvas, formatChar(chr, appendingTrans.IsUpperCase, mode))
+		if replacing != nil {
+			// the rest of a consonant cluster, e.g. the h of cc -> ch
+			for _, rule := range replacing.Rule.AppendedRules {
+				canvas = append(canvas, formatChar(rule.Result, replacing.IsUpperCase, mode))
+			}
+		}
+	}
+	return canvas
+}
+
+// getAppendingMap lists the transformations which put a character on the canvas, and maps each of
+// them to the transformations that change it
+func getAppendingMap(composition []*Transformation, mode Mode) ([]*Transformation, map[*Transformation][]*Transformation) {
 	var appendingMap = map[*Transformation][]*Transformation{}
 	var appendingList []*Transformation
 	for _, trans := range composition {
@@ -37,38 +57,70 @@ func getCanvas(composition []*Transformation, mode Mode) []rune {
 			appendingMap[trans.Target] = append(appendingMap[trans.Target], trans)
 		}
 	}
-	for _, appendingTrans := range appendingList {
-		var chr rune
-		var transList = appendingMap[appendingTrans]
-		if mode&EnglishMode != 0 {
-			chr = appendingTrans.Rule.Key
-		} else {
-			chr = appendingTrans.Rule.EffectOn
-			for _, trans := range transList {
-				switch trans.Rule.EffectType {
-				case MarkTransformation:
-					if trans.Rule.Effect == uint8(MarkRaw) {
-						chr = appendingTrans.Rule.Key
-					} else {
-						chr = AddMarkToChar(chr, trans.Rule.Effect)
-					}
-				case ToneTransformation:
-					chr = AddToneToChar(chr, trans.Rule.Effect)
-				}
+	return appendingList, appendingMap
+}
+
+// flattenChar applies the effects on an appending transformation, a replacing one is returned for
+// the rest of its consonant cluster
+func flattenChar(appendingTrans *Transformation, transList []*Transformation, mode Mode) (rune, *Transformation) {
+	if mode&EnglishMode != 0 {
+		return appendingTrans.Rule.Key, nil
+	}
+	var chr = appendingTrans.Rule.EffectOn
+	var replacing *Transformation
+	for _, trans := range transList {
+		switch trans.Rule.EffectType {
+		case MarkTransformation:
+			if trans.Rule.Effect == uint8(MarkRaw) {
+				chr = appendingTrans.Rule.Key
+			} else {
+				chr = AddMarkToChar(chr, trans.Rule.Effect)
 			}
+		case ToneTransformation:
+			chr = AddToneToChar(chr, trans.Rule.Effect)
+		case Replacing:
+			chr = trans.Rule.Result
+			replacing = trans
 		}
-		if mode&ToneLess != 0 {
-			chr = AddToneToChar(chr, 0)
-		}
-		if mode&MarkLess != 0 {
-			chr = AddMarkToChar(chr, 0)
-		}
-		if mode&LowerCase != 0 {
-			chr = unicode.ToLower(chr)
-		} else if appendingTrans.IsUpperCase {
-			chr = unicode.ToUpper(chr)
+	}
+	return chr, replacing
+}
+
+// findLastCharIndex finds the last transformation which puts a character matching f on the canvas.
+// The effects on a character come after it, so one backward pass that stops at the match is enough
+// rather than flattening every tail of the composition.
+func findLastCharIndex(composition []*Transformation, mode Mode, f func(rune) bool) int {
+	var appendingMap = map[*Transformation][]*Transformation{}
+	for i := len(composition) - 1; i >= 0; i-- {
+		var trans = composition[i]
+		if trans.Rule.Key != 0 && (mode&EnglishMode != 0 || trans.Rule.EffectType == Appending) {
+			var transList = appendingMap[trans]
+			// the effects were collected backward
+			for l, r := 0, len(transList)-1; l < r; l, r = l+1, r-1 {
+				transList[l], transList[r] = transList[r], transList[l]
+			}
+			var chr, _ = flattenChar(trans, transList, mode)
+			if f(formatChar(chr, trans.IsUpperCase, mode)) {
+				return i
+			}
+		} else if mode&EnglishMode == 0 && trans.Rule.EffectType != Appending && trans.Target != nil {
+			appendingMap[trans.Target] = append(appendingMap[trans.Target], trans)
 		}
-		canvas = append(canvas, chr)
 	}
-	return canvas
+	return -1
+}
+
+func formatChar(chr rune, isUpperCase bool, mode Mode) rune {
+	if mode&ToneLess != 0 {
+		chr = AddToneToChar(chr, 0)
+	}
+	if mode&MarkLess != 0 {
+		chr = AddMarkToChar(chr, 0)
+	}
+	if mode&LowerCase != 0 {
+		chr = unicode.ToLower(chr)
+	} else if isUpperCase {
+		chr = unicode.ToUpper(chr)
+	}
+	return chr
 }
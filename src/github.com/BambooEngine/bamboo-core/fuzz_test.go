@@ -0,0 +1,224 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+const (
+	opRemoveLastChar  = '\b'
+	opRestoreLastWord = '\x7f'
+)
+
+var fuzzFlags = []uint{EfreeToneMarking, EstdToneStyle, EautoCorrectEnabled, EquickTelex, EquickStartConsonant, EquickEndConsonant}
+
+var fuzzModes = []Mode{
+	VietnameseMode, EnglishMode, VietnameseMode | ToneLess, VietnameseMode | MarkLess,
+	VietnameseMode | ToneLess | MarkLess | LowerCase, VietnameseMode | FullText, EnglishMode | FullText,
+	PunctuationMode, VietnameseMode | FullText | InReverseOrder,
+}
+
+func fuzzInputMethods() []InputMethod {
+	var names []string
+	for name := range InputMethodDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ims []InputMethod
+	for _, name := range names {
+		ims = append(ims, ParseInputMethod(InputMethodDefinitions, name))
+	}
+	return ims
+}
+
+// flagsOf picks a combination of the engine flags from the bits of n
+func flagsOf(n int) uint {
+	var flags uint
+	for i, flag := range fuzzFlags {
+		if n&(1<<i) != 0 {
+			flags |= flag
+		}
+	}
+	return flags
+}
+
+// checkInvariants replays the operations, keys mixed with backspaces and word restores, and checks
+// the invariants of the engine after each of them
+func checkInvariants(t *testing.T, im InputMethod, flags uint, ops string) {
+	var ng = NewEngine(im, flags)
+	var raw []rune
+	// the raw keys give back the text until a word is restored or a key undoes the letter it typed
+	var isRawReplayable = true
+	for i, op := range ops {
+		var before = ng.GetProcessedString(VietnameseMode | FullText)
+		switch op {
+		case opRemoveLastChar:
+			ng.RemoveLastChar(true)
+			if isRawReplayable {
+				checkRawReplay(t, ng, ops[:i+1])
+			}
+			// the keys of the removed letter are unknown, the raw keys are checked again from here
+			raw = []rune(ng.GetProcessedString(EnglishMode | FullText))
+		case opRestoreLastWord:
+			ng.RestoreLastWord()
+			isRawReplayable = false
+			if s := ng.GetProcessedString(VietnameseMode); s != ng.GetProcessedString(EnglishMode) {
+				t.Fatalf("%s %#x [%q]: the restored word is [%s] expected [%s]", im.Name, flags, ops[:i+1], s, ng.GetProcessedString(EnglishMode))
+			}
+		default:
+			if isDoubleTyping(ng, op) {
+				isRawReplayable = false
+			} else {
+				raw = append(raw, op)
+			}
+			ng.ProcessKey(op, VietnameseMode)
+			checkSyllableAnchor(t, ng.(*BambooEngine), ops[:i+1])
+		}
+		for _, mode := range fuzzModes {
+			ng.GetProcessedString(mode)
+		}
+		var restored = NewEngine(im, flags)
+		if err := restored.(*BambooEngine).Restore(ng.(*BambooEngine).Snapshot()); err != nil {
+			t.Fatalf("%s %#x [%q]: restore the snapshot: %v", im.Name, flags, ops[:i+1], err)
+		}
+		if s := restored.GetProcessedString(VietnameseMode | FullText); s != ng.GetProcessedString(VietnameseMode|FullText) {
+			t.Fatalf("%s %#x [%q]: the restored snapshot gives [%s] expected [%s]", im.Name, flags, ops[:i+1], s, ng.GetProcessedString(VietnameseMode|FullText))
+		}
+		if s := ng.GetProcessedString(EnglishMode | FullText); s != string(raw) {
+			t.Fatalf("%s %#x [%q]: got the raw keys [%s] expected [%s]", im.Name, flags, ops[:i+1], s, string(raw))
+		}
+		if op == opRemoveLastChar || op == opRestoreLastWord {
+			continue
+		}
+		// every key is followed by a backspace on a copy of the engine
+		var replay = NewEngine(im, flags)
+		for _, key := range ops[:i+1] {
+			replayOp(replay, key)
+		}
+		replay.RemoveLastChar(true)
+		if isRawReplayable {
+			checkRawReplay(t, replay, ops[:i+1]+string(opRemoveLastChar))
+		}
+		// a key which only appends a letter is removed by a backspace
+		var after = []rune(ng.GetProcessedString(VietnameseMode | FullText))
+		if len(after) == len([]rune(before))+1 && string(after[:len(after)-1]) == before && unicode.ToLower(after[len(after)-1]) == unicode.ToLower(op) {
+			if s := replay.GetProcessedString(VietnameseMode | FullText); s != before {
+				t.Fatalf("%s %#x [%q]: backspace gives [%s] expected [%s]", im.Name, flags, ops[:i+1], s, before)
+			}
+		}
+	}
+}
+
+// checkRawReplay checks that the text left by a backspace is the one given by its raw keys, so the
+// letters removed in VietnameseMode and in EnglishMode are the same
+func checkRawReplay(t *testing.T, ng IEngine, ops string) {
+	var keys = ng.GetProcessedString(EnglishMode | FullText)
+	var reference = NewEngine(ng.GetInputMethod(), ng.(*BambooEngine).flags)
+	reference.ProcessString(keys, VietnameseMode)
+	if s, expected := ng.GetProcessedString(VietnameseMode|FullText), reference.GetProcessedString(VietnameseMode|FullText); s != expected {
+		t.Fatalf("%s %#x [%q]: backspace gives [%s] expected [%s] as the keys [%s]", ng.GetInputMethod().Name, ng.(*BambooEngine).flags, ops, s, expected, keys)
+	}
+}
+
+// isDoubleTyping tells if the key undoes the letter typed by the same key, the pair then gives only
+// one key in EnglishMode, e.g. ww -> w in Telex 2
+func isDoubleTyping(ng IEngine, key rune) bool {
+	var composition = ng.(*BambooEngine).composition
+	if len(composition) == 0 || !ng.CanProcessKey(unicode.ToLower(key)) {
+		return false
+	}
+	var rule = composition[len(composition)-1].Rule
+	return rule.EffectType == Appending && rule.Key == unicode.ToLower(key) && rule.Key != rule.Result
+}
+
+// checkSyllableAnchor checks that resuming from the remembered syllable anchor finds the same last
+// syllable as checking the whole word
+func checkSyllableAnchor(t *testing.T, e *BambooEngine, ops string) {
+	var cache = e.syllableAnchor
+	cache.word = append([]*Transformation(nil), cache.word...)
+	var _, resumed = extractLastSyllableFrom(e.composition, e.spelling(), &cache)
+	var _, scanned = extractLastSyllable(e.composition, e.spelling())
+	if len(resumed) != len(scanned) {
+		t.Fatalf("%s %#x [%q]: the last syllable has %d transformations expected %d", e.inputMethod.Name, e.flags, ops, len(resumed), len(scanned))
+	}
+}
+
+func replayOp(ng IEngine, op rune) {
+	switch op {
+	case opRemoveLastChar:
+		ng.RemoveLastChar(true)
+	case opRestoreLastWord:
+		ng.RestoreLastWord()
+	default:
+		ng.ProcessKey(op, VietnameseMode)
+	}
+}
+
+// randomOps types syllables with the keys of an input method, sometimes a backspace or a restore
+func randomOps(r *rand.Rand, im InputMethod) string {
+	const letters = "abcdeghiklmnopqrstuvxy"
+	var ops strings.Builder
+	for n := r.Intn(16) + 1; n > 0; n-- {
+		switch x := r.Intn(20); {
+		case x < 10:
+			ops.WriteByte(letters[r.Intn(len(letters))])
+		case x < 15:
+			ops.WriteRune(im.Keys[r.Intn(len(im.Keys))])
+		case x < 16:
+			ops.WriteRune(opRemoveLastChar)
+		case x < 17:
+			ops.WriteRune(opRestoreLastWord)
+		case x < 18:
+			ops.WriteRune(unicode.ToUpper(rune(letters[r.Intn(len(letters))])))
+		default:
+			ops.WriteByte(" .,1"[r.Intn(4)])
+		}
+	}
+	return ops.String()
+}
+
+func TestEngineInvariants(t *testing.T) {
+	var r = rand.New(rand.NewSource(1))
+	for _, im := range fuzzInputMethods() {
+		for n := 0; n < 1<<len(fuzzFlags); n++ {
+			for i := 0; i < 8; i++ {
+				checkInvariants(t, im, flagsOf(n), randomOps(r, im))
+			}
+		}
+	}
+}
+
+// go test -run XXX -fuzz=FuzzEngine explores more operations than TestEngineInvariants
+func FuzzEngine(f *testing.F) {
+	for _, seed := range []string{"nguwowif", "tieengs vieetj", "hoaf\b", "dduwowngf\b\b\x7f", "ngu7o7i2", "uyeenr", "cc\bgg", "Dd\x7fdd"} {
+		f.Add(uint8(0), uint8(7), seed)
+	}
+	var ims = fuzzInputMethods()
+	f.Fuzz(func(t *testing.T, im uint8, flags uint8, ops string) {
+		// a key is never 0, which marks the virtual transformations
+		ops = strings.ReplaceAll(ops, "\x00", "")
+		// a combining mark is composed with the letter before it and a key is typed in lower or upper
+		// case, neither of them is a key of a keyboard, e.g. U+0300 or the title case ǈ
+		ops = strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) || unicode.IsTitle(r) || r != unicode.ToLower(r) && unicode.ToUpper(unicode.ToLower(r)) != r {
+				return -1
+			}
+			return r
+		}, ops)
+		if len(ops) > 64 {
+			t.Skip()
+		}
+		checkInvariants(t, ims[int(im)%len(ims)], flagsOf(int(flags)), ops)
+	})
+}
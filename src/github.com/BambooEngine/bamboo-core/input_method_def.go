@@ -14,15 +14,23 @@ var InputMethodDefinitions = map[string]InputMethodDefinition{
 	"Telex": {
 		"z": "XoaDauThanh",
 		"s": "DauSac",
-		"f": "DauHuyen",
+		"f": "DauHuyen,^F>PH",
 		"r": "DauHoi",
 		"x": "DauNga",
-		"j": "DauNang",
+		"j": "DauNang,^J>GI",
 		"a": "A_Â",
 		"e": "E_Ê",
 		"o": "O_Ô",
-		"w": "UOA_ƯƠĂ",
+		"w": "UOA_ƯƠĂ,^W>QU",
 		"d": "D_Đ",
+		"c": "CC>CH",
+		"g": "GG>GI,G$>NG",
+		"h": "H$>NH",
+		"k": "KK>KH,K$>CH",
+		"n": "NN>NG",
+		"p": "PP>PH",
+		"q": "QQ>QU",
+		"t": "TT>TH",
 	},
 	"VNI": {
 		"0": "XoaDauThanh",
@@ -73,15 +81,23 @@ var InputMethodDefinitions = map[string]InputMethodDefinition{
 	"Telex 2": {
 		"z": "XoaDauThanh",
 		"s": "DauSac",
-		"f": "DauHuyen",
+		"f": "DauHuyen,^F>PH",
 		"r": "DauHoi",
 		"x": "DauNga",
-		"j": "DauNang",
+		"j": "DauNang,^J>GI",
 		"a": "A_Â",
 		"e": "E_Ê",
 		"o": "O_Ô",
 		"w": "UOA_ƯƠĂ__Ư",
 		"d": "D_Đ",
+		"c": "CC>CH",
+		"g": "GG>GI,G$>NG",
+		"h": "H$>NH",
+		"k": "KK>KH,K$>CH",
+		"n": "NN>NG",
+		"p": "PP>PH",
+		"q": "QQ>QU",
+		"t": "TT>TH",
 		"]": "__ư",
 		"[": "__ơ",
 		"}": "_Ư",
@@ -90,15 +106,23 @@ var InputMethodDefinitions = map[string]InputMethodDefinition{
 	"Telex + VNI": {
 		"z": "XoaDauThanh",
 		"s": "DauSac",
-		"f": "DauHuyen",
+		"f": "DauHuyen,^F>PH",
 		"r": "DauHoi",
 		"x": "DauNga",
-		"j": "DauNang",
+		"j": "DauNang,^J>GI",
 		"a": "A_Â",
 		"e": "E_Ê",
 		"o": "O_Ô",
-		"w": "UOA_ƯƠĂ",
+		"w": "UOA_ƯƠĂ,^W>QU",
 		"d": "D_Đ",
+		"c": "CC>CH",
+		"g": "GG>GI,G$>NG",
+		"h": "H$>NH",
+		"k": "KK>KH,K$>CH",
+		"n": "NN>NG",
+		"p": "PP>PH",
+		"q": "QQ>QU",
+		"t": "TT>TH",
 		"0": "XoaDauThanh",
 		"1": "DauSac",
 		"2": "DauHuyen",
@@ -113,15 +137,23 @@ var InputMethodDefinitions = map[string]InputMethodDefinition{
 	"Telex + VNI + VIQR": {
 		"z":  "XoaDauThanh",
 		"s":  "DauSac",
-		"f":  "DauHuyen",
+		"f":  "DauHuyen,^F>PH",
 		"r":  "DauHoi",
 		"x":  "DauNga",
-		"j":  "DauNang",
+		"j":  "DauNang,^J>GI",
 		"a":  "A_Â",
 		"e":  "E_Ê",
 		"o":  "O_Ô",
-		"w":  "UOA_ƯƠĂ",
+		"w":  "UOA_ƯƠĂ,^W>QU",
 		"d":  "D_Đ",
+		"c":  "CC>CH",
+		"g":  "GG>GI,G$>NG",
+		"h":  "H$>NH",
+		"k":  "KK>KH,K$>CH",
+		"n":  "NN>NG",
+		"p":  "PP>PH",
+		"q":  "QQ>QU",
+		"t":  "TT>TH",
 		"0":  "XoaDauThanh",
 		"1":  "DauSac",
 		"2":  "DauHuyen",
@@ -158,15 +190,23 @@ var InputMethodDefinitions = map[string]InputMethodDefinition{
 	"Telex W": {
 		"z": "XoaDauThanh",
 		"s": "DauSac",
-		"f": "DauHuyen",
+		"f": "DauHuyen,^F>PH",
 		"r": "DauHoi",
 		"x": "DauNga",
-		"j": "DauNang",
+		"j": "DauNang,^J>GI",
 		"a": "A_Â",
 		"e": "E_Ê",
 		"o": "O_Ô",
 		"w": "UOA_ƯƠĂ__Ư",
 		"d": "D_Đ",
+		"c": "CC>CH",
+		"g": "GG>GI,G$>NG",
+		"h": "H$>NH",
+		"k": "KK>KH,K$>CH",
+		"n": "NN>NG",
+		"p": "PP>PH",
+		"q": "QQ>QU",
+		"t": "TT>TH",
 	},
 }
 
@@ -0,0 +1,177 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/*
+   An input method file has one key per line, the text after # is a comment:
+
+   <key> = <effect>
+
+   The effects are written like the ones of InputMethodDefinitions: a tone (DauSac, DauHuyen, DauHoi,
+   DauNga, DauNang or XoaDauThanh), the marks that the key puts on some letters (UOA_ƯƠĂ), a letter
+   that the key appends (__ư for a lowercase one, _Ư for an uppercase one), or both (UOA_ƯƠĂ__Ư).
+   A consonant cluster replaces the letter of the key when it is typed twice (CC>CH), at the start
+   of a syllable (^F>PH) or at the end of a syllable (G$>NG), with the quick telex options. A key
+   with several effects separates them by commas, e.g. f = DauHuyen,^F>PH.
+   The keys # and \ are escaped as \# and \\.
+*/
+
+// InputMethodIssue is a mistake in the definition of an input method
+type InputMethodIssue struct {
+	Line    int // the line in the input method file, 0 if the definition has no file
+	Key     string
+	Message string
+}
+
+func (i InputMethodIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("line %d: key %q: %s", i.Line, i.Key, i.Message)
+	}
+	return fmt.Sprintf("key %q: %s", i.Key, i.Message)
+}
+
+var regEffect = regexp.MustCompile(`^(?:([a-zA-Z]+)_(\p{L}+))?(?:_?_(\p{L}+))?$`)
+
+// ParseInputMethodDefinition reads an input method file. The definition is linted like with
+// LintInputMethod; the issues tell the lines, and a key bound twice keeps its last effect.
+func ParseInputMethodDefinition(r io.Reader) (InputMethodDefinition, []InputMethodIssue, error) {
+	var def = InputMethodDefinition{}
+	var keyLines = map[string]int{}
+	var issues []InputMethodIssue
+	var scanner = bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		var key string
+		if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\\`) {
+			key, line = line[1:2], line[2:]
+		} else {
+			var runes = []rune(line)
+			key, line = string(runes[0]), string(runes[1:])
+		}
+		if i := strings.IndexRune(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		var fields = strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "=" {
+			return nil, nil, fmt.Errorf("line %d: expected <key> = <effect>", lineNo)
+		}
+		if prev, found := keyLines[key]; found {
+			issues = append(issues, InputMethodIssue{lineNo, key, fmt.Sprintf("bound twice, the effect on line %d is dropped", prev)})
+		}
+		keyLines[key] = lineNo
+		def[key] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	for _, issue := range LintInputMethod(def) {
+		issue.Line = keyLines[issue.Key]
+		issues = append(issues, issue)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+	return def, issues, nil
+}
+
+// LintInputMethod finds the effects that the rules parser would skip or misread: unknown effect
+// names, keys bound twice, rules that are never applied and letters marked in two ways by a key.
+func LintInputMethod(def InputMethodDefinition) []InputMethodIssue {
+	var keys []string
+	for key := range def {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var issues []InputMethodIssue
+	for _, key := range keys {
+		var report = func(format string, args ...interface{}) {
+			issues = append(issues, InputMethodIssue{Key: key, Message: fmt.Sprintf(format, args...)})
+		}
+		var runes = []rune(key)
+		if len(runes) != 1 {
+			report("a key must be one character")
+			continue
+		}
+		var lowerKey = unicode.ToLower(runes[0])
+		if lowerKey != runes[0] {
+			if _, found := def[string(lowerKey)]; found {
+				report("bound twice, keys are matched in lowercase like %q", string(lowerKey))
+			} else {
+				report("unreachable, keys are matched in lowercase, use %q", string(lowerKey))
+			}
+			continue
+		}
+		for _, message := range lintEffects(lowerKey, def[key]) {
+			report("%s", message)
+		}
+	}
+	return issues
+}
+
+// lintEffects lints every effect of a key, they are separated by commas
+func lintEffects(key rune, line string) []string {
+	var messages []string
+	for _, effect := range strings.Split(line, ",") {
+		messages = append(messages, lintEffect(key, effect)...)
+	}
+	return messages
+}
+
+func lintEffect(key rune, effect string) []string {
+	if _, found := tones[effect]; found {
+		return nil
+	}
+	if regReplacing.MatchString(effect) {
+		if _, ok := parseReplacingRule(key, effect); !ok {
+			return []string{fmt.Sprintf("%q must replace the key typed twice (%[2]c%[2]c>CH), at the start (^%[2]c>PH) or at the end (%[2]c$>NG)", effect, unicode.ToUpper(key))}
+		}
+		return nil
+	}
+	var parts = regEffect.FindStringSubmatch(effect)
+	if parts == nil || effect == "" {
+		return []string{fmt.Sprintf("unknown effect %q", effect)}
+	}
+	var messages []string
+	var letters, results = []rune(strings.ToLower(parts[1])), []rune(strings.ToLower(parts[2]))
+	if len(letters) != len(results) {
+		return []string{fmt.Sprintf("the letters and the results of %q differ in number", effect)}
+	}
+	var marked = map[rune]rune{}
+	for i, letter := range letters {
+		var result = results[i]
+		var mark, found = FindMarkFromChar(result)
+		if !found || mark == MarkNone || AddMarkToChar(letter, uint8(mark)) != result {
+			messages = append(messages, fmt.Sprintf("%c cannot be marked as %c", letter, result))
+			continue
+		}
+		if prev, found := marked[letter]; found {
+			if prev == result {
+				messages = append(messages, fmt.Sprintf("unreachable, %c is marked as %c twice", letter, result))
+			} else {
+				messages = append(messages, fmt.Sprintf("ambiguous mark target, %c is marked as %c, not %c", letter, prev, result))
+			}
+			continue
+		}
+		marked[letter] = result
+	}
+	return messages
+}
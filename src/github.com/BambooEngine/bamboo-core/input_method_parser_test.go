@@ -0,0 +1,89 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintDefaultInputMethods(t *testing.T) {
+	for name, def := range InputMethodDefinitions {
+		if issues := LintInputMethod(def); len(issues) > 0 {
+			t.Errorf("Lint %s, got %v", name, issues)
+		}
+	}
+}
+
+func TestParseInputMethodDefinition(t *testing.T) {
+	var file = `# Telex with brackets
+s = DauSac
+f = DauHuyen  # grave
+w = UOA_ƯƠĂ__Ư
+\# = _Ư
+\\ = __ư
+d = D_Đ
+`
+	var def, issues, err = ParseInputMethodDefinition(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = InputMethodDefinition{"s": "DauSac", "f": "DauHuyen", "w": "UOA_ƯƠĂ__Ư", "#": "_Ư", "\\": "__ư", "d": "D_Đ"}
+	if !reflect.DeepEqual(def, expected) || len(issues) > 0 {
+		t.Errorf("Parse an input method, got %v %v expected %v", def, issues, expected)
+	}
+	var im = parseInputMethods(map[string]InputMethodDefinition{"File": def})["File"]
+	if s := Transliterate("DDuwowngf #\\", im, EstdFlags, RestorePolicy{}); s != "Đường Ưư" {
+		t.Errorf("Transliterate with the parsed input method, got [%s] expected [Đường Ưư]", s)
+	}
+	if _, _, err := ParseInputMethodDefinition(strings.NewReader("s DauSac")); err == nil {
+		t.Errorf("Parse a line without =, expected an error")
+	}
+}
+
+func TestInputMethodIssues(t *testing.T) {
+	var file = `s = DauSak
+w = UOA_ƯƠĂ__
+a = A_Ê
+o = OO_ÔƠ
+e = EE_ÊÊ
+u = UO_Ư
+W = __w
+j = DauNang
+j = DauNga
+c = DauSac,CD>CH
+`
+	var _, issues, err = ParseInputMethodDefinition(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	var expected = []string{
+		`line 1: key "s": unknown effect "DauSak"`,
+		`line 2: key "w": unknown effect "UOA_ƯƠĂ__"`,
+		`line 3: key "a": a cannot be marked as ê`,
+		`line 4: key "o": ambiguous mark target, o is marked as ô, not ơ`,
+		`line 5: key "e": unreachable, e is marked as ê twice`,
+		`line 6: key "u": the letters and the results of "UO_Ư" differ in number`,
+		`line 7: key "W": bound twice, keys are matched in lowercase like "w"`,
+		`line 9: key "j": bound twice, the effect on line 8 is dropped`,
+		`line 10: key "c": "CD>CH" must replace the key typed twice (CC>CH), at the start (^C>PH) or at the end (C$>NG)`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Lint an input method, got\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+	var def = InputMethodDefinition{"W": "__w", "ab": "DauSac"}
+	if issues := LintInputMethod(def); len(issues) != 2 || issues[0].Message != `unreachable, keys are matched in lowercase, use "w"` || issues[1].Key != "ab" {
+		t.Errorf("Lint a definition, got %v", issues)
+	}
+}
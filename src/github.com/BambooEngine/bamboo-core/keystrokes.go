@@ -0,0 +1,206 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type keystrokeStyle struct {
+	preferAppending bool // type ư as [ in the Microsoft layout rather than uw
+	marksAtEnd      bool // nguoiwf rather than nguwowif
+	toneAfterVowel  bool // vieejt rather than vieetj
+	escapeRepeats   bool // ooong for oong in Telex, the third o undoes the circumflex
+}
+
+// The styles are tried in order, the first one that gives back the word wins
+var keystrokeStyles = []keystrokeStyle{
+	{},
+	{toneAfterVowel: true},
+	{marksAtEnd: true},
+	{preferAppending: true},
+	{preferAppending: true, toneAfterVowel: true},
+	{marksAtEnd: true, toneAfterVowel: true},
+	{escapeRepeats: true},
+	{escapeRepeats: true, toneAfterVowel: true},
+}
+
+// Keystrokes generates the key strokes to type a Vietnamese text with an input method, e.g.
+// "người" gives "nguwowif" in Telex and "ngu7o7i2" in VNI. The key strokes give back the text
+// when they are processed with ProcessString by an engine created with the same flags.
+func Keystrokes(text string, inputMethod InputMethod, flags uint) (string, error) {
+	var engine = NewEngine(inputMethod, flags)
+	var keys strings.Builder
+	var word []rune
+	var flush = func() error {
+		if len(word) == 0 {
+			return nil
+		}
+		var wordKeys, ok = wordKeystrokes(engine, string(word))
+		if !ok {
+			return fmt.Errorf("cannot type %q with %s", string(word), inputMethod.Name)
+		}
+		keys.WriteString(wordKeys)
+		word = word[:0]
+		return nil
+	}
+	for _, chr := range text {
+		if unicode.IsLetter(chr) {
+			word = append(word, chr)
+			continue
+		}
+		if err := flush(); err != nil {
+			return "", err
+		}
+		keys.WriteRune(chr)
+	}
+	if err := flush(); err != nil {
+		return "", err
+	}
+	engine.Reset()
+	engine.ProcessString(keys.String(), VietnameseMode)
+	if engine.GetProcessedString(VietnameseMode|FullText) != text {
+		return "", fmt.Errorf("cannot type %q with %s", text, inputMethod.Name)
+	}
+	return keys.String(), nil
+}
+
+func wordKeystrokes(engine IEngine, word string) (string, bool) {
+	var isUpperWord = strings.ToUpper(word) == word
+	for _, upperEffectKeys := range []bool{isUpperWord, false} {
+		for _, style := range keystrokeStyles {
+			var keys, ok = style.keystrokes(engine.GetInputMethod(), word, upperEffectKeys)
+			if !ok {
+				continue
+			}
+			engine.Reset()
+			engine.ProcessString(keys, VietnameseMode)
+			if engine.GetProcessedString(VietnameseMode) == word {
+				return keys, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (style keystrokeStyle) keystrokes(im InputMethod, word string, upperEffectKeys bool) (string, bool) {
+	var keys, markKeys, toneKeys []rune
+	var effectKey = func(key rune) rune {
+		if upperEffectKeys {
+			return unicode.ToUpper(key)
+		}
+		return key
+	}
+	for _, chr := range word {
+		var lowerChr = unicode.ToLower(chr)
+		var isUpperCase = chr != lowerChr
+		var tone = FindToneFromChar(lowerChr)
+		var base = AddToneToChar(lowerChr, 0)
+		var root, markKey, found = findMarkKey(im, base)
+		var appendingKey, hasAppending = findAppendingKey(im, base, isUpperCase)
+		switch {
+		case !IsVietnameseRune(base):
+			var key = AddToneToChar(chr, 0)
+			if style.escapeRepeats && len(keys) > 0 && unicode.ToLower(keys[len(keys)-1]) == base && inKeyList(im.Keys, base) {
+				keys = append(keys, key)
+			}
+			keys = append(keys, key)
+		case hasAppending && (style.preferAppending || !found):
+			keys = append(keys, appendingKey)
+		case found:
+			if isUpperCase {
+				root = unicode.ToUpper(root)
+			}
+			keys = append(keys, root)
+			if style.marksAtEnd {
+				markKeys = append(markKeys, effectKey(markKey))
+			} else {
+				keys = append(keys, effectKey(markKey))
+			}
+		default:
+			return "", false
+		}
+		if tone == ToneNone {
+			continue
+		}
+		var toneKey, ok = findToneKey(im, tone)
+		if !ok {
+			return "", false
+		}
+		if style.toneAfterVowel {
+			keys = append(keys, effectKey(toneKey))
+		} else {
+			toneKeys = append(toneKeys, effectKey(toneKey))
+		}
+	}
+	keys = append(keys, markKeys...)
+	keys = append(keys, toneKeys...)
+	return string(keys), true
+}
+
+// isPreferredKey keeps the key strokes stable as the rules come from a map: letters first, then the
+// lowest key, e.g. s rather than 1 for the acute tone in Telex + VNI
+func isPreferredKey(key, other rune) bool {
+	if IsAlpha(key) != IsAlpha(other) {
+		return IsAlpha(key)
+	}
+	return key < other
+}
+
+// findMarkKey finds the rule that turns a plain letter into the given one, e.g. a + w for ă in Telex
+func findMarkKey(im InputMethod, chr rune) (rune, rune, bool) {
+	var root, key rune
+	var found bool
+	for _, rule := range im.Rules {
+		if rule.EffectType != MarkTransformation || rule.Effect == 0 || rule.Result != chr || rule.EffectOn >= unicode.MaxASCII {
+			continue
+		}
+		if !found || isPreferredKey(rule.Key, key) {
+			root, key, found = rule.EffectOn, rule.Key, true
+		}
+	}
+	return root, key, found
+}
+
+// findAppendingKey finds the key that types the given letter directly, e.g. [ for ư in the Microsoft layout
+func findAppendingKey(im InputMethod, chr rune, isUpperCase bool) (rune, bool) {
+	var key, caseKey rune
+	var found, caseFound bool
+	for _, rule := range im.Rules {
+		if rule.EffectType != Appending || len(rule.AppendedRules) > 0 || unicode.ToLower(rule.Result) != chr {
+			continue
+		}
+		if unicode.IsUpper(rule.Result) == isUpperCase && (!caseFound || isPreferredKey(rule.Key, caseKey)) {
+			caseKey, caseFound = rule.Key, true
+		}
+		if !found || isPreferredKey(rule.Key, key) {
+			key, found = rule.Key, true
+		}
+	}
+	if caseFound {
+		return caseKey, true
+	}
+	if found && isUpperCase {
+		key = unicode.ToUpper(key)
+	}
+	return key, found
+}
+
+func findToneKey(im InputMethod, tone Tone) (rune, bool) {
+	var key rune
+	var found bool
+	for _, rule := range im.Rules {
+		if rule.EffectType == ToneTransformation && rule.GetTone() == tone && (!found || isPreferredKey(rule.Key, key)) {
+			key, found = rule.Key, true
+		}
+	}
+	return key, found
+}
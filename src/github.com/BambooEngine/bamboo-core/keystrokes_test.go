@@ -0,0 +1,89 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"testing"
+)
+
+func TestKeystrokes(t *testing.T) {
+	var tests = []struct {
+		im       string
+		text     string
+		expected string
+	}{
+		{"Telex", "người", "nguwowif"},
+		{"Telex", "Việt Nam", "Vieetj Nam"},
+		{"Telex", "ĐƯỢC", "DDUWOWCJ"},
+		{"Telex", "quốc", "quoocs"},
+		{"Telex", "boong", "booong"},
+		{"Telex", "năm 1999, tháng 9.", "nawm 1999, thangs 9."},
+		{"VNI", "người", "ngu7o7i2"},
+		{"VNI", "đường", "d9u7o7ng2"},
+		{"VNI", "thuở", "thuo73"},
+		{"VIQR", "giữa", "giu*a~"},
+		{"Microsoft layout", "người", "ng[]i5"},
+		{"Microsoft layout", "nguyệt", "nguy3t9"},
+		{"Microsoft layout", "khuyến", "khuy3n8"},
+		{"Telex + VNI", "người", "nguwowif"},
+	}
+	for _, test := range tests {
+		var im = ParseInputMethod(InputMethodDefinitions, test.im)
+		if keys, err := Keystrokes(test.text, im, EstdFlags); err != nil || keys != test.expected {
+			t.Errorf("Keystrokes [%s] with %s, got [%s] (%v) expected [%s]", test.text, test.im, keys, err, test.expected)
+		}
+	}
+}
+
+func TestKeystrokesError(t *testing.T) {
+	var telex = ParseInputMethod(InputMethodDefinitions, "Telex")
+	// the standard tone style puts the tone on the o
+	if _, err := Keystrokes("hoà", telex, EstdFlags); err == nil {
+		t.Errorf("Keystrokes [hoà] with the standard tone style, expected an error")
+	}
+	if keys, err := Keystrokes("hoà", telex, EstdFlags&^EstdToneStyle); err != nil || keys != "hoaf" {
+		t.Errorf("Keystrokes [hoà], got [%s] (%v) expected [hoaf]", keys, err)
+	}
+}
+
+func TestKeystrokesRoundTrip(t *testing.T) {
+	DefaultSpelling.syllablesOnce.Do(DefaultSpelling.buildSyllables)
+	// the syllables are typed in both tone styles, e.g. hòa with the standard one and hoà without it
+	var styles = []struct {
+		flags    uint
+		stdStyle bool
+	}{
+		{EstdFlags, true},
+		{EstdFlags &^ EstdToneStyle, false},
+	}
+	// a run over all the syllables takes minutes, each input method and tone style samples every
+	// 37th syllable from its own offset
+	const step = 37
+	var run = 0
+	for name := range InputMethodDefinitions {
+		var im = ParseInputMethod(InputMethodDefinitions, name)
+		for _, style := range styles {
+			var engine = NewEngine(im, style.flags)
+			for i := run % step; i < len(DefaultSpelling.syllables); i += step {
+				var word = DefaultSpelling.syllables[i].wordInStyle(style.stdStyle)
+				var keys, err = Keystrokes(word, im, style.flags)
+				if err != nil {
+					t.Errorf("Keystrokes [%s] with %s, got error %v", word, name, err)
+					continue
+				}
+				engine.Reset()
+				engine.ProcessString(keys, VietnameseMode)
+				if s := engine.GetProcessedString(VietnameseMode); s != word {
+					t.Errorf("Process [%s] with %s, got [%s] expected [%s]", keys, name, s, word)
+				}
+			}
+			run++
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize places the tones of the Vietnamese words in a text like the engine does with
+// EstdToneStyle on or off, e.g. "hoà" becomes "hòa" with stdStyle and "thúy" becomes "thuý"
+// without. A toned i after qu is spelt y in both styles, e.g. "quí" becomes "quý", while a word
+// without a tone such as "qui" is kept as it is.
+func Normalize(text string, stdStyle bool) string {
+	var out strings.Builder
+	var word []rune
+	var flush = func() {
+		out.WriteString(normalizeWord(word, stdStyle))
+		word = word[:0]
+	}
+	for _, c := range text {
+		if unicode.IsLetter(c) {
+			word = append(word, c)
+			continue
+		}
+		flush()
+		out.WriteRune(c)
+	}
+	flush()
+	return out.String()
+}
+
+func normalizeWord(word []rune, stdStyle bool) string {
+	word = spellQuiAsQuy(word)
+	var composition []*Transformation
+	for _, c := range word {
+		composition = append(composition, decomposeChar(c)...)
+	}
+	if !DefaultSpelling.isValid(composition, true) {
+		return string(word)
+	}
+	composition = append(composition, refreshLastToneTarget(composition, stdStyle)...)
+	return Flatten(composition, VietnameseMode)
+}
+
+// spellQuiAsQuy replaces the toned i of qu + i + consonants with y, e.g. quí -> quý, quít -> quýt
+func spellQuiAsQuy(word []rune) []rune {
+	if len(word) < 3 || unicode.ToLower(word[0]) != 'q' || unicode.ToLower(word[1]) != 'u' {
+		return word
+	}
+	var i = unicode.ToLower(word[2])
+	var tone = FindToneFromChar(i)
+	if AddToneToChar(i, 0) != 'i' || tone == ToneNone {
+		return word
+	}
+	for _, c := range word[3:] {
+		if IsVowel(unicode.ToLower(c)) {
+			return word
+		}
+	}
+	var y = AddToneToChar('y', uint8(tone))
+	if unicode.IsUpper(word[2]) {
+		y = unicode.ToUpper(y)
+	}
+	return append(append([]rune{}, word[:2]...), append([]rune{y}, word[3:]...)...)
+}
@@ -0,0 +1,55 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	var tests = []struct {
+		text   string
+		std    string
+		modern string
+	}{
+		{"hoà bình, Hòa Bình", "hòa bình, Hòa Bình", "hoà bình, Hoà Bình"},
+		{"thuý và thúy", "thúy và thúy", "thuý và thuý"},
+		{"khoẻ KHỎE", "khỏe KHỎE", "khoẻ KHOẺ"},
+		{"quí quý qúy", "quý quý quý", "quý quý quý"},
+		{"QUÍT Quịt qui quit", "QUÝT Quỵt qui quit", "QUÝT Quỵt qui quit"},
+		{"thuở xoá hoàng", "thuở xóa hoàng", "thuở xoá hoàng"},
+		{"café 123 hoà.", "café 123 hòa.", "café 123 hoà."},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if s := Normalize(test.text, true); s != test.std {
+			t.Errorf("Normalize [%s] in the standard style, got [%s] expected [%s]", test.text, s, test.std)
+		}
+		if s := Normalize(test.text, false); s != test.modern {
+			t.Errorf("Normalize [%s], got [%s] expected [%s]", test.text, s, test.modern)
+		}
+	}
+}
+
+func TestNormalizeLikeEngine(t *testing.T) {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex")
+	for _, flags := range []uint{EfreeToneMarking, EfreeToneMarking | EstdToneStyle} {
+		var ng = NewEngine(im, flags)
+		for _, keys := range []string{"hoaf", "thuyr", "khoer", "tuyeetj", "nguowif", "gif", "quaf"} {
+			ng.Reset()
+			ng.ProcessString(keys, VietnameseMode)
+			var expected = ng.GetProcessedString(VietnameseMode)
+			for _, word := range []string{Normalize(expected, flags&EstdToneStyle == 0), expected} {
+				if s := Normalize(word, flags&EstdToneStyle != 0); s != expected {
+					t.Errorf("Normalize [%s] with flags %d, got [%s] expected [%s]", word, flags, s, expected)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+// newReplacingRule turns the letter effectOn into a consonant cluster. A virtual replacing rule
+// (key 0) does not appear in the raw string.
+func newReplacingRule(key, effectOn rune, cluster string) Rule {
+	var chars = []rune(cluster)
+	var rule = Rule{
+		Key:        key,
+		EffectType: Replacing,
+		EffectOn:   effectOn,
+		Result:     chars[0],
+	}
+	for _, chr := range chars[1:] {
+		rule.AppendedRules = append(rule.AppendedRules, Rule{
+			Key:        key,
+			EffectType: Appending,
+			EffectOn:   chr,
+			Result:     chr,
+		})
+	}
+	return rule
+}
+
+// newReplacingTrans replaces the character of target with the consonant cluster of rule
+func newReplacingTrans(rule Rule, target *Transformation, isUpperCase bool) *Transformation {
+	rule.EffectOn = target.Rule.Result
+	return &Transformation{
+		Rule:        rule,
+		Target:      target,
+		IsUpperCase: isUpperCase,
+	}
+}
+
+// findReplacingRule finds the rule of a key which replaces its letter at the given place
+func findReplacingRule(rules []Rule, replacement Replacement) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.EffectType == Replacing && rule.GetReplacement() == replacement {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func findLastReplacingTrans(composition []*Transformation, target *Transformation) *Transformation {
+	for i := len(composition) - 1; i >= 0; i-- {
+		if composition[i].Target == target && composition[i].Rule.EffectType == Replacing {
+			return composition[i]
+		}
+	}
+	return nil
+}
+
+/**
+* 1 | c + c      ->  replacing           -> ch  (EquickTelex, CC>CH)
+* 2 | ch + c     ->  undo + append       -> cc
+* 3 | f          ->  append + replacing  -> ph  (EquickStartConsonant, ^F>PH, f appends no letter)
+* 4 | a + h      ->  append + replacing  -> anh (EquickEndConsonant, H$>NH)
+**/
+func generateQuickTransformations(composition []*Transformation, rules []Rule, flags uint, lowerKey rune, isUpperCase bool, spelling *Spelling) []*Transformation {
+	var last *Transformation
+	if len(composition) > 0 {
+		last = composition[len(composition)-1]
+	}
+	if flags&EquickTelex != 0 && last != nil {
+		if last.Rule.EffectType == Replacing && last.Rule.Key == lowerKey {
+			// typing the key again undoes the replacing
+			var undo = newReplacingRule(0, last.Target.Rule.EffectOn, string(last.Target.Rule.EffectOn))
+			return []*Transformation{
+				newReplacingTrans(undo, last.Target, false),
+				newAppendingTrans(lowerKey, isUpperCase),
+			}
+		}
+		if rule, ok := findReplacingRule(rules, ReplaceDoubledKey); ok && last.Rule.EffectType == Appending &&
+			last.Rule.Key == lowerKey && last.Rule.EffectOn == lowerKey && findLastReplacingTrans(composition, last) == nil {
+			var trans = newReplacingTrans(rule, last, isUpperCase)
+			if spelling.isValid(append(composition, trans), false) {
+				return []*Transformation{trans}
+			}
+		}
+	}
+	var rule Rule
+	var found bool
+	var _, vo, lc = extractCvcTrans(composition)
+	// an uppercase F is Ph while an uppercase H after a vowel is NH
+	var isClusterUpperCase = false
+	if flags&EquickStartConsonant != 0 && len(composition) == 0 && !hasAppendingRule(rules) {
+		rule, found = findReplacingRule(rules, ReplaceAtStart)
+	} else if flags&EquickEndConsonant != 0 && len(vo) > 0 && len(lc) == 0 {
+		rule, found = findReplacingRule(rules, ReplaceAtEnd)
+		isClusterUpperCase = isUpperCase
+	}
+	if found {
+		var appending = newAppendingTrans(lowerKey, isUpperCase)
+		if len(composition) > 0 && spelling.isValid(append(composition, appending), true) {
+			// the key is a valid last consonant by itself
+			return nil
+		}
+		// the cluster is virtual, the key is typed once by the appending
+		rule.Key = 0
+		rule.AppendedRules = append([]Rule(nil), rule.AppendedRules...)
+		for i := range rule.AppendedRules {
+			rule.AppendedRules[i].Key = 0
+		}
+		var trans = []*Transformation{appending, newReplacingTrans(rule, appending, isClusterUpperCase)}
+		if spelling.isValid(append(composition, trans...), false) {
+			return trans
+		}
+	}
+	return nil
+}
+
+// isUndoingTrans tells whether trans is the virtual replacing which undoes a consonant cluster of
+// quick telex, the cluster is replaced by a key before it
+func isUndoingTrans(trans *Transformation, composition []*Transformation) bool {
+	if trans.Rule.EffectType != Replacing || trans.Rule.Key != 0 {
+		return false
+	}
+	var replacing = findLastReplacingTrans(composition, trans.Target)
+	return replacing != nil && replacing.Rule.Key != 0
+}
+
+func hasAppendingRule(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.EffectType == Appending {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"testing"
+)
+
+func newQuickTelexEngine(flags uint) IEngine {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex")
+	return NewEngine(im, EstdFlags|flags)
+}
+
+func TestQuickTelex(t *testing.T) {
+	var tests = []struct {
+		flags    uint
+		keys     string
+		expected string
+	}{
+		{EquickTelex, "ccaos", "cháo"},
+		{EquickTelex, "gga", "gia"},
+		{EquickTelex, "kkoong", "không"},
+		{EquickTelex, "nnuwowif", "người"},
+		{EquickTelex, "qqaf", "quà"},
+		{EquickTelex, "ppos", "phó"},
+		{EquickTelex, "ttuw", "thư"},
+		{EquickTelex, "Ccaa", "Châ"},
+		{EquickTelex, "CCAA", "CHÂ"},
+		{EquickTelex, "vieett", "viêtt"}, // th is not a last consonant
+		{EquickTelex, "ccc", "cc"},       // undo on a repeated key
+		{EquickTelex, "ttta", "tta"},
+		{EquickStartConsonant, "fos", "phó"},
+		{EquickStartConsonant, "jaf", "già"},
+		{EquickStartConsonant, "wa", "qua"},
+		{EquickStartConsonant, "Fa", "Pha"},
+		{EquickEndConsonant, "bag", "bang"},
+		{EquickEndConsonant, "ahf", "ành"},
+		{EquickEndConsonant, "sachs", "sách"},
+		{EquickEndConsonant, "saks", "sách"},
+		{EquickEndConsonant, "ANH", "ANH"},
+		{0, "ccaos", "ccáo"},
+		{0, "fa", "fa"},
+		{0, "bag", "bag"},
+	}
+	for _, test := range tests {
+		ng := newQuickTelexEngine(test.flags)
+		ng.ProcessString(test.keys, VietnameseMode)
+		if s := ng.GetProcessedString(VietnameseMode); s != test.expected {
+			t.Errorf("Process [%s] with flags %d, got [%s] expected [%s]", test.keys, test.flags, s, test.expected)
+		}
+		if s := ng.GetProcessedString(EnglishMode); s != test.keys {
+			t.Errorf("Process [%s] with flags %d in English mode, got [%s] expected [%s]", test.keys, test.flags, s, test.keys)
+		}
+	}
+}
+
+func TestQuickStartConsonantAppendingKey(t *testing.T) {
+	// w is ư in Telex 2, it is not turned into qu
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex 2")
+	for keys, expected := range map[string]string{"wf": "ừ", "wowcs": "ước", "wa": "ưa", "fa": "pha"} {
+		ng := NewEngine(im, EstdFlags|EquickStartConsonant)
+		ng.ProcessString(keys, VietnameseMode)
+		if s := ng.GetProcessedString(VietnameseMode); s != expected {
+			t.Errorf("Process [%s] in Telex 2, got [%s] expected [%s]", keys, s, expected)
+		}
+	}
+}
+
+func TestQuickTelexOutsideTelex(t *testing.T) {
+	// VNI does not define the replacing rules, the quick telex options change nothing
+	var im = ParseInputMethod(InputMethodDefinitions, "VNI")
+	for keys, expected := range map[string]string{"cca1": "ccá", "fa": "fa", "bag": "bag"} {
+		ng := NewEngine(im, EstdFlags|EquickTelex|EquickStartConsonant|EquickEndConsonant)
+		ng.ProcessString(keys, VietnameseMode)
+		if s := ng.GetProcessedString(VietnameseMode); s != expected {
+			t.Errorf("Process [%s] in VNI, got [%s] expected [%s]", keys, s, expected)
+		}
+	}
+	// a custom definition carries only the clusters it declares
+	var def = map[string]InputMethodDefinition{"Custom": {"s": "DauSac", "c": "CC>CH", "z": "^Z>GI"}}
+	for keys, expected := range map[string]string{"ccas": "chá", "zas": "giá", "ttas": "ttá"} {
+		ng := NewEngine(ParseInputMethod(def, "Custom"), EstdFlags|EquickTelex|EquickStartConsonant)
+		ng.ProcessString(keys, VietnameseMode)
+		if s := ng.GetProcessedString(VietnameseMode); s != expected {
+			t.Errorf("Process [%s] in a custom input method, got [%s] expected [%s]", keys, s, expected)
+		}
+	}
+}
+
+func TestQuickTelexRemoveLastChar(t *testing.T) {
+	var tests = []struct {
+		flags    uint
+		keys     string
+		expected string
+		raw      string
+	}{
+		{EquickTelex, "nnaf", "ng", "nn"},
+		{EquickTelex, "cc", "c", "c"},
+		{EquickTelex, "ccc", "ch", "cc"}, // the undo of ch is removed with the last c
+		{EquickTelex, "ccaa", "ch", "cc"},
+		{EquickStartConsonant, "fa", "ph", "f"},
+		{EquickEndConsonant, "bag", "ba", "ba"},
+	}
+	for _, test := range tests {
+		ng := newQuickTelexEngine(test.flags)
+		ng.ProcessString(test.keys, VietnameseMode)
+		ng.RemoveLastChar(true)
+		if s := ng.GetProcessedString(VietnameseMode); s != test.expected {
+			t.Errorf("Remove the last char of [%s], got [%s] expected [%s]", test.keys, s, test.expected)
+		}
+		if s := ng.GetProcessedString(EnglishMode); s != test.raw {
+			t.Errorf("Remove the last char of [%s] in English mode, got [%s] expected [%s]", test.keys, s, test.raw)
+		}
+	}
+	ng := newQuickTelexEngine(EquickTelex)
+	ng.ProcessString("ccc", VietnameseMode)
+	for _, expected := range []string{"ch", "c", ""} {
+		ng.RemoveLastChar(true)
+		if s := ng.GetProcessedString(VietnameseMode); s != expected {
+			t.Errorf("Remove the chars of [ccc] one by one, got [%s] expected [%s]", s, expected)
+		}
+	}
+}
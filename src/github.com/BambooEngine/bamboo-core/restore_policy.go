@@ -0,0 +1,76 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"strings"
+)
+
+// RestorePolicy tells when a word that is not Vietnamese is restored to its key strokes. The IBus
+// engine and Transliterate share it, so a text converted by Transliterate reads like the one typed.
+type RestorePolicy struct {
+	AutoRestore bool // restore the words that are not Vietnamese
+	DdFreeStyle bool // keep đ in any word, dd is used a lot in abbreviations
+	// InDictionary, if set, checks a complete word instead of the spelling rules
+	InDictionary func(word string) bool
+	// PreferEnglish, if set, tells whether the key strokes form an English word that should not be
+	// converted, even without AutoRestore
+	PreferEnglish func(keys, word string) bool
+}
+
+// ShouldRestore tells whether the word being typed is shown with its key strokes. With checkVnRune, a
+// word without Vietnamese letters is shown as it is.
+func (p RestorePolicy) ShouldRestore(ng IEngine, checkVnRune bool) bool {
+	if p.prefersEnglish(ng) {
+		return true
+	}
+	if !p.AutoRestore {
+		return false
+	}
+	var vnSeq = ng.GetProcessedString(VietnameseMode | LowerCase)
+	var vnRunes = []rune(vnSeq)
+	if len(vnRunes) == 0 {
+		return false
+	}
+	if p.DdFreeStyle && (vnRunes[len(vnRunes)-1] == 'd' || strings.ContainsRune(vnSeq, 'đ')) {
+		return false
+	}
+	if checkVnRune && !HasAnyVietnameseRune(vnSeq) {
+		return false
+	}
+	return !ng.IsValid(false)
+}
+
+// MustRestore tells whether the word is restored to its key strokes once it is complete, e.g. at a space
+func (p RestorePolicy) MustRestore(ng IEngine) bool {
+	if p.prefersEnglish(ng) {
+		return true
+	}
+	if !p.AutoRestore {
+		return false
+	}
+	var vnSeq = ng.GetProcessedString(VietnameseMode | LowerCase)
+	if vnSeq == "" {
+		return false
+	}
+	if p.DdFreeStyle && strings.ContainsRune(vnSeq, 'đ') {
+		return false
+	}
+	if p.InDictionary != nil {
+		return !p.InDictionary(vnSeq)
+	}
+	return !ng.IsValid(true)
+}
+
+func (p RestorePolicy) prefersEnglish(ng IEngine) bool {
+	if p.PreferEnglish == nil {
+		return false
+	}
+	return p.PreferEnglish(ng.GetProcessedString(EnglishMode|LowerCase), ng.GetProcessedString(VietnameseMode|LowerCase))
+}
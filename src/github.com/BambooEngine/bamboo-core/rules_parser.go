@@ -11,6 +11,7 @@ package bamboo
 import (
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 var tones = map[string]Tone{
@@ -54,9 +55,18 @@ const (
 	ToneDot   Tone = iota
 )
 
+// Replacement is where a replacing rule turns the letter of its key into a consonant cluster
+type Replacement uint8
+
+const (
+	ReplaceDoubledKey Replacement = iota << 0 // the key typed twice, e.g. cc -> ch
+	ReplaceAtStart    Replacement = iota      // the key at the start of a syllable, e.g. f -> ph
+	ReplaceAtEnd      Replacement = iota      // the key at the end of a syllable, e.g. g -> ng
+)
+
 type Rule struct {
 	Key           rune
-	Effect        uint8 // (Tone, Mark)
+	Effect        uint8 // (Tone, Mark, Replacement)
 	EffectType    EffectType
 	EffectOn      rune
 	Result        rune
@@ -79,6 +89,10 @@ func (r *Rule) GetMark() Mark {
 	return Mark(r.Effect)
 }
 
+func (r *Rule) GetReplacement() Replacement {
+	return Replacement(r.Effect)
+}
+
 type InputMethod struct {
 	Name          string
 	Rules         []Rule
@@ -86,6 +100,8 @@ type InputMethod struct {
 	ToneKeys      []rune
 	AppendingKeys []rune
 	Keys          []rune
+	Spelling      *Spelling // the spelling rules that syllables are checked with, DefaultSpelling if nil
+	rulesByKey    map[rune][]Rule
 }
 
 func ParseInputMethod(imDef map[string]InputMethodDefinition, imName string) InputMethod {
@@ -121,25 +137,74 @@ func parseInputMethods(imDef map[string]InputMethodDefinition) map[string]InputM
 				im.ToneKeys = append(im.ToneKeys, rule.Key)
 			}
 		}
+		im.rulesByKey = indexRules(im.Rules)
 		inputMethods[name] = im
 	}
 	return inputMethods
 }
 
+// indexRules groups the rules by their keys, so a key stroke does not go through all of them
+func indexRules(rules []Rule) map[rune][]Rule {
+	var index = map[rune][]Rule{}
+	for _, rule := range rules {
+		index[rule.Key] = append(index[rule.Key], rule)
+	}
+	for key, keyRules := range index {
+		index[key] = keyRules[:len(keyRules):len(keyRules)]
+	}
+	return index
+}
+
+// ParseRules reads the effects of a key, separated by commas, e.g. "DauHuyen,^F>PH"
 func ParseRules(key rune, line string) []Rule {
 	var rules []Rule
-	if tone, ok := tones[line]; ok {
-		var rule Rule
-		rule.Key = key
-		rule.EffectType = ToneTransformation
-		rule.Effect = uint8(tone)
-		rules = append(rules, rule)
-	} else {
-		rules = ParseTonelessRules(key, line)
+	for _, effect := range strings.Split(line, ",") {
+		if tone, ok := tones[effect]; ok {
+			var rule Rule
+			rule.Key = key
+			rule.EffectType = ToneTransformation
+			rule.Effect = uint8(tone)
+			rules = append(rules, rule)
+		} else if rule, ok := parseReplacingRule(key, effect); ok {
+			rules = append(rules, rule)
+		} else {
+			rules = append(rules, ParseTonelessRules(key, effect)...)
+		}
 	}
 	return rules
 }
 
+var regReplacing = regexp.MustCompile(`^(\^?)(\p{L}+)(\$?)>(\p{L}+)$`)
+
+// parseReplacingRule reads a consonant cluster that replaces the letter of the key: CC>CH when the
+// key is typed twice, ^F>PH at the start of a syllable and G$>NG at the end of a syllable
+func parseReplacingRule(key rune, effect string) (Rule, bool) {
+	var parts = regReplacing.FindStringSubmatch(strings.ToLower(effect))
+	if parts == nil {
+		return Rule{}, false
+	}
+	var letters = []rune(parts[2])
+	for _, letter := range letters {
+		if letter != unicode.ToLower(key) {
+			return Rule{}, false
+		}
+	}
+	var replacement Replacement
+	switch {
+	case parts[1] == "" && parts[3] == "" && len(letters) == 2:
+		replacement = ReplaceDoubledKey
+	case parts[1] == "^" && parts[3] == "" && len(letters) == 1:
+		replacement = ReplaceAtStart
+	case parts[1] == "" && parts[3] == "$" && len(letters) == 1:
+		replacement = ReplaceAtEnd
+	default:
+		return Rule{}, false
+	}
+	var rule = newReplacingRule(key, letters[0], parts[4])
+	rule.Effect = uint8(replacement)
+	return rule, true
+}
+
 var regDsl = regexp.MustCompile(`([a-zA-Z]+)_(\p{L}+)([_\p{L}]*)`)
 
 func ParseTonelessRules(key rune, line string) []Rule {
@@ -149,6 +214,9 @@ func ParseTonelessRules(key rune, line string) []Rule {
 		effectiveOns := []rune(parts[1])
 		results := []rune(parts[2])
 		for i, effectiveOn := range effectiveOns {
+			if i >= len(results) {
+				break
+			}
 			effect, found := FindMarkFromChar(results[i])
 			if !found {
 				continue
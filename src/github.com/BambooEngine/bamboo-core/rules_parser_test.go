@@ -139,3 +139,35 @@ func TestAppendRule(t *testing.T) {
 
 func TestParseRulesWithIm(t *testing.T) {
 }
+
+func TestParseReplacingRules(t *testing.T) {
+	var tests = []struct {
+		key         rune
+		line        string
+		replacement Replacement
+		cluster     string
+	}{
+		{'c', "CC>CH", ReplaceDoubledKey, "ch"},
+		{'f', "DauHuyen,^F>PH", ReplaceAtStart, "ph"},
+		{'g', "GG>GI,G$>NG", ReplaceAtEnd, "ng"},
+	}
+	for _, test := range tests {
+		var rules = ParseRules(test.key, test.line)
+		var last = rules[len(rules)-1]
+		if last.EffectType != Replacing || last.GetReplacement() != test.replacement || last.EffectOn != test.key {
+			t.Errorf("Parse [%s], got %v", test.line, rules)
+		}
+		var cluster = []rune{last.Result}
+		for _, rule := range last.AppendedRules {
+			cluster = append(cluster, rule.Result)
+		}
+		if string(cluster) != test.cluster {
+			t.Errorf("Parse [%s], got the cluster %q expected %q", test.line, string(cluster), test.cluster)
+		}
+	}
+	for _, line := range []string{"CD>CH", "C>CH", "^CC>CH", "^C$>CH"} {
+		if _, ok := parseReplacingRule('c', line); ok {
+			t.Errorf("Parse [%s], got a replacing rule", line)
+		}
+	}
+}
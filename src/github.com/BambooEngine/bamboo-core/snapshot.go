@@ -0,0 +1,68 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"fmt"
+)
+
+// Snapshot is the composition of an engine in a serializable form, e.g. with encoding/json
+type Snapshot struct {
+	Transformations []SnapshotTransformation
+}
+
+// SnapshotTransformation is a transformation whose target is the index of another one in the
+// snapshot, or -1
+type SnapshotTransformation struct {
+	Rule        Rule
+	Target      int
+	IsUpperCase bool
+}
+
+func (e *BambooEngine) Snapshot() Snapshot {
+	var indexes = make(map[*Transformation]int, len(e.composition))
+	for i, trans := range e.composition {
+		indexes[trans] = i
+	}
+	var snapshot = Snapshot{Transformations: make([]SnapshotTransformation, len(e.composition))}
+	for i, trans := range e.composition {
+		var target = -1
+		if trans.Target != nil {
+			target = indexes[trans.Target]
+		}
+		snapshot.Transformations[i] = SnapshotTransformation{
+			Rule:        trans.Rule,
+			Target:      target,
+			IsUpperCase: trans.IsUpperCase,
+		}
+	}
+	return snapshot
+}
+
+// Restore replaces the composition by the one of the snapshot, it is left untouched if the snapshot
+// is broken
+func (e *BambooEngine) Restore(snapshot Snapshot) error {
+	var composition = make([]*Transformation, len(snapshot.Transformations))
+	for i := range composition {
+		composition[i] = new(Transformation)
+	}
+	for i, st := range snapshot.Transformations {
+		if st.Target < -1 || st.Target >= len(composition) || st.Target == i {
+			return fmt.Errorf("transformation %d has an invalid target %d", i, st.Target)
+		}
+		composition[i].Rule = st.Rule
+		composition[i].IsUpperCase = st.IsUpperCase
+		if st.Target >= 0 {
+			composition[i].Target = composition[st.Target]
+		}
+	}
+	e.composition = composition
+	e.syllableAnchor = syllableAnchor{}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	var ng = newStdEngine().(*BambooEngine)
+	ng.ProcessString("tieengs vieej", VietnameseMode)
+	var data, err = json.Marshal(ng.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	var restored = newStdEngine().(*BambooEngine)
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if s := restored.GetProcessedString(VietnameseMode | FullText); s != "tiếng việ" {
+		t.Errorf("Restore [tieengs vieej], got [%s] expected [tiếng việ]", s)
+	}
+	// the tone moves to the new vowel target like in the original engine
+	restored.ProcessString("t", VietnameseMode)
+	if s := restored.GetProcessedString(VietnameseMode); s != "việt" {
+		t.Errorf("Restore then process [t], got [%s] expected [việt]", s)
+	}
+	restored.RemoveLastChar(true)
+	restored.RemoveLastChar(true)
+	if s := restored.GetProcessedString(VietnameseMode); s != "vi" {
+		t.Errorf("Restore then remove 2 chars, got [%s] expected [vi]", s)
+	}
+	if s := restored.GetProcessedString(EnglishMode | FullText); s != "tieengs vi" {
+		t.Errorf("Restore then remove 2 chars, got [%s] expected [tieengs vi]", s)
+	}
+}
+
+func TestRestoreInvalidSnapshot(t *testing.T) {
+	var ng = newStdEngine().(*BambooEngine)
+	ng.ProcessString("aa", VietnameseMode)
+	var snapshot = ng.Snapshot()
+	snapshot.Transformations[1].Target = 5
+	if err := ng.Restore(snapshot); err == nil {
+		t.Errorf("Restore a snapshot with an invalid target, expected an error")
+	}
+	if s := ng.GetProcessedString(VietnameseMode); s != "â" {
+		t.Errorf("Restore an invalid snapshot, got [%s] expected [â]", s)
+	}
+	if err := ng.Restore(Snapshot{}); err != nil || ng.GetProcessedString(VietnameseMode) != "" {
+		t.Errorf("Restore an empty snapshot, got [%s] (%v) expected []", ng.GetProcessedString(VietnameseMode), err)
+	}
+}
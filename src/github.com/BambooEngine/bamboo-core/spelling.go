@@ -8,6 +8,12 @@
 
 package bamboo
 
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
 var firstConsonantSeqs = []string{
 	"b d đ g gh m n nh p ph r s t tr v z",
 	"c h k kh qu th",
@@ -49,6 +55,50 @@ var vcMatrix = [7][]int{
 	{3},
 }
 
+// the last consonants that only go with the acute and dot tones
+var stopConsonants = []string{"c", "k", "p", "t", "ch"}
+
+// Spelling holds the tables that syllables are checked with: the groups of first consonants, vowels
+// and last consonants, the vowel groups that each first consonant group goes with (cv) and the last
+// consonant groups that each vowel group goes with (vc). The default tables are the Vietnamese ones;
+// ParseSpelling extends them or replaces them, e.g. for loanwords or the Latin orthographies of
+// minority languages.
+type Spelling struct {
+	firstConsonants []string // a group per row, in the format of firstConsonantSeqs
+	vowels          []string
+	lastConsonants  []string
+	cv              [][]int
+	vc              [][]int
+	stopConsonants  []string
+	// the group names used in spelling files
+	firstConsonantNames, vowelNames, lastConsonantNames []string
+
+	syllables     []syllable // for suggestions
+	syllablesOnce sync.Once
+}
+
+// DefaultSpelling is used by the input methods that have no spelling rules of their own.
+var DefaultSpelling = &Spelling{
+	firstConsonants:     firstConsonantSeqs,
+	vowels:              vowelSeqs,
+	lastConsonants:      lastConsonantSeqs,
+	cv:                  cvMatrix[:],
+	vc:                  vcMatrix[:],
+	stopConsonants:      stopConsonants,
+	firstConsonantNames: groupNames(len(firstConsonantSeqs)),
+	vowelNames:          groupNames(len(vowelSeqs)),
+	lastConsonantNames:  groupNames(len(lastConsonantSeqs)),
+}
+
+// groupNames names the groups of the default tables by their row numbers
+func groupNames(n int) []string {
+	var names = make([]string, n)
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+	}
+	return names
+}
+
 func lookup(seq []string, input string, inputIsFull, inputIsComplete bool) []int {
 	var ret []int
 	var inputLen = len([]rune(input))
@@ -80,48 +130,97 @@ func lookup(seq []string, input string, inputIsFull, inputIsComplete bool) []int
 	return ret
 }
 
-func isValidCVC(fc, vo, lc string, inputIsFullComplete bool) bool {
-	var ret bool
+// Verdict tells why a syllable is not a valid Vietnamese syllable
+type Verdict uint8
+
+const (
+	VerdictValid             Verdict = iota
+	VerdictInvalidOnset              // e.g. "f" in "fa"
+	VerdictInvalidVowel              // e.g. "ae" in "bae"
+	VerdictInvalidCoda               // e.g. "nk" in "bank"
+	VerdictInvalidOnsetVowel         // the first consonant does not go with the vowel, e.g. "boăn", see cvMatrix
+	VerdictInvalidVowelCoda          // the vowel does not go with the last consonant, e.g. "ưch", see vcMatrix
+	VerdictInvalidTone               // the tone does not go with a stop last consonant, e.g. "àt"
+)
+
+var verdictNames = []string{
+	"valid",
+	"invalid onset",
+	"invalid vowel cluster",
+	"invalid coda",
+	"onset not allowed with the vowel",
+	"vowel not allowed with the coda",
+	"tone not allowed with a stop coda",
+}
+
+func (v Verdict) String() string {
+	if int(v) < len(verdictNames) {
+		return verdictNames[v]
+	}
+	return "unknown"
+}
+
+// Diagnosis is the verdict on a syllable with the parts that it was checked by
+type Diagnosis struct {
+	Verdict Verdict
+	Onset   string // the first consonant
+	Vowel   string // without the tone
+	Coda    string // the last consonant
+	Tone    Tone
+}
+
+func (d Diagnosis) String() string {
+	if d.Verdict == VerdictValid {
+		return d.Verdict.String()
+	}
+	return fmt.Sprintf("%s [%s|%s|%s]", d.Verdict, d.Onset, d.Vowel, d.Coda)
+}
+
+func (s *Spelling) isValidCVC(fc, vo, lc string, inputIsFullComplete bool) bool {
+	return s.diagnoseCVC(fc, vo, lc, inputIsFullComplete) == VerdictValid
+}
+
+func (s *Spelling) diagnoseCVC(fc, vo, lc string, inputIsFullComplete bool) Verdict {
 	var fcIndexes, voIndexes, lcIndexes []int
-	// log.Printf("fc=%s vo=%s lc=%s ret=%v", fc, vo, lc, ret)
+	// log.Printf("fc=%s vo=%s lc=%s", fc, vo, lc)
 	if fc != "" {
-		if fcIndexes = lookup(firstConsonantSeqs, fc, inputIsFullComplete || vo != "", true); fcIndexes == nil {
-			return false
+		if fcIndexes = lookup(s.firstConsonants, fc, inputIsFullComplete || vo != "", true); fcIndexes == nil {
+			return VerdictInvalidOnset
 		}
 	}
 	if vo != "" {
-		if voIndexes = lookup(vowelSeqs, vo, inputIsFullComplete || lc != "", inputIsFullComplete); voIndexes == nil {
-			return false
+		if voIndexes = lookup(s.vowels, vo, inputIsFullComplete || lc != "", inputIsFullComplete); voIndexes == nil {
+			return VerdictInvalidVowel
 		}
 	}
 	if lc != "" {
-		if lcIndexes = lookup(lastConsonantSeqs, lc, inputIsFullComplete, true); lcIndexes == nil {
-			return false
+		if lcIndexes = lookup(s.lastConsonants, lc, inputIsFullComplete, true); lcIndexes == nil {
+			return VerdictInvalidCoda
 		}
 	}
 	if voIndexes == nil {
 		// first consonant only
-		return fcIndexes != nil
+		if fcIndexes == nil {
+			return VerdictInvalidOnset
+		}
+		return VerdictValid
 	}
 	if fcIndexes != nil {
 		// first consonant + vowel
-		if ret = isValidCV(fcIndexes, voIndexes); !ret || lcIndexes == nil {
-			return ret
+		if !s.isValidCV(fcIndexes, voIndexes) {
+			return VerdictInvalidOnsetVowel
 		}
 	}
-	if lcIndexes != nil {
+	if lcIndexes != nil && !s.isValidVC(voIndexes, lcIndexes) {
 		// vowel + last consonant
-		ret = isValidVC(voIndexes, lcIndexes)
-	} else {
-		// vowel only
-		ret = true
+		return VerdictInvalidVowelCoda
 	}
-	return ret
+	return VerdictValid
 }
 
-func isValidCV(fcIndexes, voIndexes []int) bool {
+func (s *Spelling) isValidCV(fcIndexes, voIndexes []int) bool {
 	for _, fc := range fcIndexes {
-		for _, c := range cvMatrix[fc] {
+		for _, c := range s.cv[fc] {
 			for _, vo := range voIndexes {
 				if c == vo {
 					return true
@@ -132,9 +231,9 @@ func isValidCV(fcIndexes, voIndexes []int) bool {
 	return false
 }
 
-func isValidVC(voIndexes, lcIndexes []int) bool {
+func (s *Spelling) isValidVC(voIndexes, lcIndexes []int) bool {
 	for _, vo := range voIndexes {
-		for _, c := range vcMatrix[vo] {
+		for _, c := range s.vc[vo] {
 			for _, lc := range lcIndexes {
 				if c == lc {
 					return true
@@ -0,0 +1,207 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+   A spelling file has one rule per line, the text after # is a comment:
+
+   onset <group> = <first consonant>...   adds first consonants to a group
+   vowel <group> = <vowel>...             adds vowels without tones to a group
+   coda <group> = <last consonant>...     adds last consonants to a group
+   onset+vowel <group> = <vowel group>... lets the first consonants go with the vowels
+   vowel+coda <group> = <coda group>...   lets the vowels go with the last consonants
+   stop = <last consonant>...             the last consonants that only go with the acute and dot tones
+   clear                                  drops the rules read so far, including the default ones
+
+   The groups of DefaultSpelling are named by their row numbers in spelling.go.
+*/
+
+type spellingGroups struct {
+	names []string
+	seqs  [][]string
+	pairs [][]string // the names of the groups that each group goes with
+}
+
+func (g *spellingGroups) index(name string) int {
+	for i, n := range g.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *spellingGroups) group(name string) int {
+	var i = g.index(name)
+	if i < 0 {
+		g.names = append(g.names, name)
+		g.seqs = append(g.seqs, nil)
+		g.pairs = append(g.pairs, nil)
+		i = len(g.names) - 1
+	}
+	return i
+}
+
+func (g *spellingGroups) rows() []string {
+	var rows = make([]string, len(g.seqs))
+	for i, seqs := range g.seqs {
+		rows[i] = strings.Join(seqs, " ")
+	}
+	return rows
+}
+
+// matrix resolves the pairs to the indexes of the other groups
+func (g *spellingGroups) matrix(other *spellingGroups) ([][]int, error) {
+	var matrix = make([][]int, len(g.pairs))
+	for i, pairs := range g.pairs {
+		matrix[i] = []int{}
+		for _, name := range pairs {
+			var j = other.index(name)
+			if j < 0 {
+				return nil, fmt.Errorf("unknown group %q paired with %q", name, g.names[i])
+			}
+			matrix[i] = append(matrix[i], j)
+		}
+	}
+	return matrix, nil
+}
+
+func newSpellingGroups(rows []string, names []string, matrix [][]int, otherNames []string) *spellingGroups {
+	var g = &spellingGroups{}
+	for i, row := range rows {
+		g.names = append(g.names, names[i])
+		g.seqs = append(g.seqs, strings.Fields(row))
+		var pairs = []string{}
+		if matrix != nil {
+			for _, j := range matrix[i] {
+				pairs = append(pairs, otherNames[j])
+			}
+		}
+		g.pairs = append(g.pairs, pairs)
+	}
+	return g
+}
+
+// ParseSpelling reads a spelling file over the rules of base, which may be nil to start from none.
+func ParseSpelling(r io.Reader, base *Spelling) (*Spelling, error) {
+	var onsets, vowels, codas = &spellingGroups{}, &spellingGroups{}, &spellingGroups{}
+	var stops []string
+	if base != nil {
+		onsets = newSpellingGroups(base.firstConsonants, base.firstConsonantNames, base.cv, base.vowelNames)
+		vowels = newSpellingGroups(base.vowels, base.vowelNames, base.vc, base.lastConsonantNames)
+		codas = newSpellingGroups(base.lastConsonants, base.lastConsonantNames, nil, nil)
+		stops = append(stops, base.stopConsonants...)
+	}
+	var scanner = bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		var line = scanner.Text()
+		if i := strings.IndexRune(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if line == "clear" {
+			onsets, vowels, codas = &spellingGroups{}, &spellingGroups{}, &spellingGroups{}
+			stops = nil
+			continue
+		}
+		var eq = strings.IndexRune(line, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: missing =", lineNo)
+		}
+		var head, values = strings.Fields(line[:eq]), strings.Fields(strings.ToLower(line[eq+1:]))
+		if len(head) == 1 && head[0] == "stop" {
+			stops = values
+			continue
+		}
+		if len(head) != 2 {
+			return nil, fmt.Errorf("line %d: expected <kind> <group> = ...", lineNo)
+		}
+		switch head[0] {
+		case "onset":
+			var i = onsets.group(head[1])
+			onsets.seqs[i] = append(onsets.seqs[i], values...)
+		case "vowel":
+			var i = vowels.group(head[1])
+			vowels.seqs[i] = append(vowels.seqs[i], values...)
+		case "coda":
+			var i = codas.group(head[1])
+			codas.seqs[i] = append(codas.seqs[i], values...)
+		case "onset+vowel":
+			var i = onsets.group(head[1])
+			onsets.pairs[i] = append(onsets.pairs[i], values...)
+		case "vowel+coda":
+			var i = vowels.group(head[1])
+			vowels.pairs[i] = append(vowels.pairs[i], values...)
+		default:
+			return nil, fmt.Errorf("line %d: unknown kind %q", lineNo, head[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	cv, err := onsets.matrix(vowels)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := vowels.matrix(codas)
+	if err != nil {
+		return nil, err
+	}
+	return &Spelling{
+		firstConsonants:     onsets.rows(),
+		vowels:              vowels.rows(),
+		lastConsonants:      codas.rows(),
+		cv:                  cv,
+		vc:                  vc,
+		stopConsonants:      stops,
+		firstConsonantNames: onsets.names,
+		vowelNames:          vowels.names,
+		lastConsonantNames:  codas.names,
+	}, nil
+}
+
+// String writes the rules in the format of ParseSpelling.
+func (s *Spelling) String() string {
+	var b strings.Builder
+	var writeRows = func(kind string, names, rows []string) {
+		for i, row := range rows {
+			fmt.Fprintf(&b, "%s %s = %s\n", kind, names[i], row)
+		}
+	}
+	var writeMatrix = func(kind string, names []string, matrix [][]int, otherNames []string) {
+		for i, cols := range matrix {
+			var pairs []string
+			for _, j := range cols {
+				pairs = append(pairs, otherNames[j])
+			}
+			fmt.Fprintf(&b, "%s %s = %s\n", kind, names[i], strings.Join(pairs, " "))
+		}
+	}
+	b.WriteString("# first consonants\n")
+	writeRows("onset", s.firstConsonantNames, s.firstConsonants)
+	b.WriteString("# vowels\n")
+	writeRows("vowel", s.vowelNames, s.vowels)
+	b.WriteString("# last consonants\n")
+	writeRows("coda", s.lastConsonantNames, s.lastConsonants)
+	b.WriteString("# the vowels that each group of first consonants goes with\n")
+	writeMatrix("onset+vowel", s.firstConsonantNames, s.cv, s.vowelNames)
+	b.WriteString("# the last consonants that each group of vowels goes with\n")
+	writeMatrix("vowel+coda", s.vowelNames, s.vc, s.lastConsonantNames)
+	fmt.Fprintf(&b, "stop = %s\n", strings.Join(s.stopConsonants, " "))
+	return b.String()
+}
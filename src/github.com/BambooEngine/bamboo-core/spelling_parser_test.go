@@ -0,0 +1,91 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"strings"
+	"testing"
+)
+
+func newSpellingEngine(spelling *Spelling) IEngine {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex 2")
+	im.Spelling = spelling
+	return NewEngine(im, EstdFlags)
+}
+
+func TestParseDefaultSpelling(t *testing.T) {
+	s, err := ParseSpelling(strings.NewReader(DefaultSpelling.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != DefaultSpelling.String() {
+		t.Errorf("Parse the default spelling, got\n%s\nexpected\n%s", s, DefaultSpelling)
+	}
+	// every sequence and prefix, valid or not, gets the same verdict
+	var fcs = append(splitSeqs(firstConsonantSeqs), "", "f", "n", "q", "gị")
+	var vos = append(splitSeqs(vowelSeqs), "", "ae", "ư", "uy")
+	var lcs = append(splitSeqs(lastConsonantSeqs), "", "nk", "n", "g")
+	for _, fc := range fcs {
+		for _, vo := range vos {
+			for _, lc := range lcs {
+				for _, full := range []bool{false, true} {
+					if v, expected := s.diagnoseCVC(fc, vo, lc, full), DefaultSpelling.diagnoseCVC(fc, vo, lc, full); v != expected {
+						t.Errorf("Diagnose [%s|%s|%s] %v with the parsed spelling, got %s expected %s", fc, vo, lc, full, v, expected)
+					}
+				}
+			}
+		}
+	}
+	var words = []string{"vieetj", "nghieeng", "khuyeenr", "ddaatf", "tuwowngf", "chuyeenj", "quaays", "gioongs", "haft", "bank"}
+	for _, word := range words {
+		var ng, expected = newSpellingEngine(s), newStdEngine()
+		ng.ProcessString(word, VietnameseMode)
+		expected.ProcessString(word, VietnameseMode)
+		if ng.GetProcessedString(VietnameseMode) != expected.GetProcessedString(VietnameseMode) || ng.IsValid(true) != expected.IsValid(true) {
+			t.Errorf("Process [%s] with the parsed spelling, got [%s] expected [%s]", word, ng.GetProcessedString(VietnameseMode), expected.GetProcessedString(VietnameseMode))
+		}
+	}
+}
+
+func TestParseSpellingExtension(t *testing.T) {
+	var loanwords = `
+# loanwords and names
+onset foreign = f j w z
+onset+vowel foreign = 0 1 2 5
+coda foreign = f l s
+vowel+coda 2 = foreign
+`
+	s, err := ParseSpelling(strings.NewReader(loanwords), DefaultSpelling)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, word := range []string{"fa", "jun", "ol", "bos", "ddi"} {
+		ng := newSpellingEngine(s)
+		ng.ProcessString(word, VietnameseMode)
+		if !ng.IsValid(true) {
+			t.Errorf("Check [%s] with the loanword spelling, got %s", word, ng.(*BambooEngine).Diagnose(true))
+		}
+	}
+	if DefaultSpelling.isValidCVC("f", "a", "", true) {
+		t.Errorf("Extending the spelling changed the default one")
+	}
+	s, err = ParseSpelling(strings.NewReader("clear\nonset c = b\nvowel v = a\nonset+vowel c = v\nstop = p"), DefaultSpelling)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.isValidCVC("b", "a", "", true) || s.isValidCVC("m", "a", "", true) || s.isValidCVC("b", "e", "", true) {
+		t.Errorf("Clear the default spelling, got\n%s", s)
+	}
+	var tests = []string{"onset+vowel 0 = nothing", "onset 0 b", "syllable 0 = ba", "onset = b"}
+	for _, test := range tests {
+		if _, err := ParseSpelling(strings.NewReader(test), DefaultSpelling); err == nil {
+			t.Errorf("Parse [%s], expected an error", test)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"strings"
+	"testing"
+)
+
+// seqRows returns each sequence of a table with the rows that it is found in
+func seqRows(seqs []string) map[string][]int {
+	var rows = map[string][]int{}
+	for i, row := range seqs {
+		for _, s := range strings.Fields(row) {
+			rows[s] = append(rows[s], i)
+		}
+	}
+	return rows
+}
+
+func isAllowed(matrix [][]int, rows, cols []int) bool {
+	for _, r := range rows {
+		for _, c := range matrix[r] {
+			for _, col := range cols {
+				if c == col {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestDiagnoseCvMatrix(t *testing.T) {
+	var covered = map[[2]int]bool{}
+	for fc, fcRows := range seqRows(firstConsonantSeqs) {
+		for vo, voRows := range seqRows(vowelSeqs) {
+			var expected = VerdictInvalidOnsetVowel
+			if isAllowed(cvMatrix[:], fcRows, voRows) {
+				expected = VerdictValid
+			}
+			if v := DefaultSpelling.diagnoseCVC(fc, vo, "", true); v != expected {
+				t.Errorf("Diagnose [%s|%s], got %s expected %s", fc, vo, v, expected)
+			}
+			for _, r := range fcRows {
+				for _, c := range voRows {
+					covered[[2]int{r, c}] = true
+				}
+			}
+		}
+	}
+	if len(covered) != len(cvMatrix)*len(vowelSeqs) {
+		t.Errorf("Diagnose the cvMatrix, got %d cells expected %d", len(covered), len(cvMatrix)*len(vowelSeqs))
+	}
+}
+
+func TestDiagnoseVcMatrix(t *testing.T) {
+	var covered = map[[2]int]bool{}
+	for vo, voRows := range seqRows(vowelSeqs) {
+		for lc, lcRows := range seqRows(lastConsonantSeqs) {
+			var expected = VerdictInvalidVowelCoda
+			if isAllowed(vcMatrix[:], voRows, lcRows) {
+				expected = VerdictValid
+			}
+			if v := DefaultSpelling.diagnoseCVC("", vo, lc, true); v != expected {
+				t.Errorf("Diagnose [|%s|%s], got %s expected %s", vo, lc, v, expected)
+			}
+			for _, r := range voRows {
+				for _, c := range lcRows {
+					covered[[2]int{r, c}] = true
+				}
+			}
+		}
+	}
+	if len(covered) != len(vcMatrix)*len(lastConsonantSeqs) {
+		t.Errorf("Diagnose the vcMatrix, got %d cells expected %d", len(covered), len(vcMatrix)*len(lastConsonantSeqs))
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	var tests = []struct {
+		keys     string
+		expected Verdict
+	}{
+		{"vieetj", VerdictValid},
+		{"qa", VerdictInvalidOnset},
+		{"baet", VerdictInvalidVowel},
+		{"bank", VerdictInvalidCoda},
+		{"tuwch", VerdictInvalidVowelCoda},
+		{"haft", VerdictInvalidTone},
+	}
+	for _, test := range tests {
+		ng := newStdEngine().(*BambooEngine)
+		ng.ProcessString(test.keys, VietnameseMode)
+		if d := ng.Diagnose(true); d.Verdict != test.expected {
+			t.Errorf("Diagnose [%s], got %s expected %s", test.keys, d, test.expected)
+		}
+	}
+	if v := DefaultSpelling.diagnoseCVC("b", "oă", "n", true); v != VerdictInvalidOnsetVowel {
+		t.Errorf("Diagnose [b|oă|n], got %s expected %s", v, VerdictInvalidOnsetVowel)
+	}
+	if v := DefaultSpelling.diagnoseCVC("b", "", "", false); v != VerdictValid {
+		t.Errorf("Diagnose an incomplete [b], got %s expected %s", v, VerdictValid)
+	}
+}
@@ -0,0 +1,320 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"sort"
+	"strings"
+)
+
+// SuggestMaxCost is how far a suggestion may be from the typed syllable. A tone move costs nothing,
+// a wrong tone, a swapped mark or a commonly confused consonant costs 1, other edits cost 2.
+const SuggestMaxCost = 3
+
+// the consonants that are often typed in place of each other, by sound or by dialect
+var confusedConsonants = [][]string{
+	{"s", "x"},
+	{"ch", "tr"},
+	{"d", "gi", "r", "v"},
+	{"l", "n"},
+	{"c", "k", "q"},
+	{"g", "gh"},
+	{"ng", "ngh"},
+	{"c", "t"},
+	{"n", "ng"},
+	{"ch", "t"},
+	{"nh", "n"},
+}
+
+// the vowels that only go with a last consonant
+var closedVowels = []string{"â", "ă", "iê", "yê", "uyê", "uô", "ươ", "oo", "oă", "uâ"}
+
+type syllable struct {
+	word       string // e.g. "việt"
+	fc, vo, lc string // without the tone, e.g. "v", "iê" and "t"
+	tone       Tone
+}
+
+// wordInStyle returns the word with its tone in the old style if stdStyle is set, or in the new
+// style otherwise. The i of gi carries the tone in both, e.g. "gìn", the vowel is empty then.
+func (sy syllable) wordInStyle(stdStyle bool) string {
+	if stdStyle || sy.vo == "" {
+		return sy.word
+	}
+	return sy.fc + placeTone(sy.vo, sy.lc, sy.tone, false) + sy.lc
+}
+
+func splitSeqs(seqs []string) []string {
+	var ret []string
+	var seen = map[string]bool{}
+	for _, row := range seqs {
+		for _, s := range strings.Fields(row) {
+			if !seen[s] {
+				seen[s] = true
+				ret = append(ret, s)
+			}
+		}
+	}
+	return ret
+}
+
+// isValidSpelling checks the spelling rules that isValidCVC does not, e.g. "k" and "gh" go before
+// "e", "ê" and "i" while "c" and "g" do not.
+func isValidSpelling(fc, vo, lc string) bool {
+	var first = []rune(vo)[0]
+	var isFront = first == 'e' || first == 'ê' || first == 'i' || first == 'y'
+	switch fc {
+	case "k", "gh", "ngh":
+		if !isFront {
+			return false
+		}
+	case "c", "ng":
+		if isFront || fc == "c" && isRoundedVowel(vo) {
+			return false
+		}
+	case "b", "m", "p", "ph", "v":
+		if isRoundedVowel(vo) {
+			return false
+		}
+	case "g":
+		// "gi" is a consonant before the other vowels
+		if first == 'e' || first == 'ê' || first == 'y' || first == 'i' && vo != "i" && !strings.HasPrefix(vo, "iê") {
+			return false
+		}
+	case "gi":
+		if first == 'i' || first == 'y' {
+			return false
+		}
+	case "qu":
+		if first == 'u' || first == 'o' {
+			return false
+		}
+	}
+	if strings.HasPrefix(vo, "iê") && fc == "" || strings.HasPrefix(vo, "yê") && fc != "" && fc != "qu" {
+		return false
+	}
+	if lc == "" {
+		for _, v := range closedVowels {
+			if vo == v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isRoundedVowel tells whether a vowel starts with the "o" or "u" glide, which "c" is spelled "qu" with
+func isRoundedVowel(vo string) bool {
+	for _, prefix := range []string{"oa", "oă", "oe", "uy", "uâ", "uê"} {
+		if strings.HasPrefix(vo, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Spelling) isStopConsonant(lc string) bool {
+	for _, c := range s.stopConsonants {
+		if c == lc {
+			return true
+		}
+	}
+	return false
+}
+
+// placeTone puts a tone on the vowel that findToneTarget would choose in the old style if stdStyle
+// is set, e.g. "hòa", or in the new style otherwise, e.g. "hoà".
+func placeTone(vo, lc string, tone Tone, stdStyle bool) string {
+	var vowels = []rune(vo)
+	var target = 0
+	if len(vowels) == 2 && stdStyle {
+		if lc != "" {
+			target = 1
+		}
+		for i, v := range vowels {
+			if v == 'ơ' || v == 'ê' {
+				target = i
+			}
+		}
+	} else if len(vowels) == 2 {
+		var bare = string([]rune{AddMarkToTonelessChar(vowels[0], 0), AddMarkToTonelessChar(vowels[1], 0)})
+		if lc != "" || bare == "oa" || bare == "oe" || bare == "uy" || bare == "ue" || bare == "uo" {
+			target = 1
+		}
+	} else if len(vowels) == 3 {
+		if vo == "uyê" {
+			target = 2
+		} else {
+			target = 1
+		}
+	}
+	vowels[target] = AddToneToChar(vowels[target], uint8(tone))
+	return string(vowels)
+}
+
+func (s *Spelling) buildSyllables() {
+	var seen = map[string]bool{}
+	var fcs = append([]string{""}, splitSeqs(s.firstConsonants)...)
+	var lcs = append([]string{""}, splitSeqs(s.lastConsonants)...)
+	for _, fc := range fcs {
+		for _, vo := range splitSeqs(s.vowels) {
+			for _, lc := range lcs {
+				if !s.isValidCVC(fc, vo, lc, true) || !isValidSpelling(fc, vo, lc) {
+					continue
+				}
+				for tone := ToneNone; tone <= ToneDot; tone++ {
+					if s.isStopConsonant(lc) && tone != ToneAcute && tone != ToneDot {
+						continue
+					}
+					var word = fc + placeTone(vo, lc, tone, true) + lc
+					if !seen[word] {
+						seen[word] = true
+						// split it again as a typed word would be, e.g. "giếng" into "gi", "ê" and "ng"
+						var sy = syllable{word: word}
+						sy.fc, sy.vo, sy.lc, sy.tone = splitSyllable(word)
+						s.syllables = append(s.syllables, sy)
+					}
+				}
+			}
+		}
+	}
+}
+
+// splitSyllable splits a lowercase word into its first consonant, vowel and last consonant without
+// the tone, like extractCvcTrans does with a composition.
+func splitSyllable(word string) (string, string, string, Tone) {
+	var tone = ToneNone
+	var runes = []rune(word)
+	for i, c := range runes {
+		if t := FindToneFromChar(c); t != ToneNone {
+			tone = t
+		}
+		runes[i] = AddToneToChar(c, 0)
+	}
+	var i, j = 0, len(runes)
+	for i < len(runes) && !IsVowel(runes[i]) {
+		i++
+	}
+	// "gi" and "qu" are consonants when a vowel follows them
+	if i < len(runes)-1 && (string(runes[:i+1]) == "gi" || string(runes[:i+1]) == "qu") {
+		i++
+	}
+	for j > i && !IsVowel(runes[j-1]) {
+		j--
+	}
+	return string(runes[:i]), string(runes[i:j]), string(runes[j:]), tone
+}
+
+func consonantCost(a, b string) int {
+	if a == b {
+		return 0
+	}
+	for _, group := range confusedConsonants {
+		var hasA, hasB bool
+		for _, c := range group {
+			hasA = hasA || c == a
+			hasB = hasB || c == b
+		}
+		if hasA && hasB {
+			return 1
+		}
+	}
+	return editCost(a, b)
+}
+
+// editCost is the Damerau-Levenshtein distance where an edit costs 2, or 1 if it only changes a mark.
+func editCost(a, b string) int {
+	var s, t = []rune(a), []rune(b)
+	var d = make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = 2 * i
+	}
+	for j := range d[0] {
+		d[0][j] = 2 * j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			var cost = 2
+			if s[i-1] == t[j-1] {
+				cost = 0
+			} else if AddMarkToTonelessChar(s[i-1], 0) == AddMarkToTonelessChar(t[j-1], 0) {
+				cost = 1
+			}
+			d[i][j] = minCost(d[i-1][j]+2, d[i][j-1]+2, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = minCost(d[i][j], d[i-2][j-2]+2)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func minCost(costs ...int) int {
+	var ret = costs[0]
+	for _, c := range costs[1:] {
+		if c < ret {
+			ret = c
+		}
+	}
+	return ret
+}
+
+// Suggest returns up to limit valid syllables that are close to word, the closest first. It covers
+// tones on the wrong vowel, swapped marks, e.g. "ô" for "ơ", and confused consonants, e.g. "s" for "x".
+// The tones are placed in the old style if stdStyle is set, like Normalize does.
+func Suggest(word string, limit int, stdStyle bool) []string {
+	return DefaultSpelling.Suggest(word, limit, stdStyle)
+}
+
+// Suggest returns up to limit syllables close to word that are valid by these spelling rules.
+func (s *Spelling) Suggest(word string, limit int, stdStyle bool) []string {
+	s.syllablesOnce.Do(s.buildSyllables)
+	var fc, vo, lc, tone = splitSyllable(strings.ToLower(word))
+	type candidate struct {
+		word string
+		cost int
+	}
+	var candidates []candidate
+	// there are only a few distinct consonants and vowels, so their costs are computed once
+	var fcCosts, voCosts, lcCosts = map[string]int{}, map[string]int{}, map[string]int{}
+	var costOf = func(costs map[string]int, a, b string, f func(string, string) int) int {
+		if c, ok := costs[b]; ok {
+			return c
+		}
+		costs[b] = f(a, b)
+		return costs[b]
+	}
+	for _, sy := range s.syllables {
+		var cost = costOf(fcCosts, fc, sy.fc, consonantCost)
+		if cost > SuggestMaxCost {
+			continue
+		}
+		if cost += costOf(lcCosts, lc, sy.lc, consonantCost); cost > SuggestMaxCost {
+			continue
+		}
+		if sy.tone != tone {
+			cost++
+		}
+		if cost += costOf(voCosts, vo, sy.vo, editCost); cost <= SuggestMaxCost {
+			candidates = append(candidates, candidate{sy.wordInStyle(stdStyle), cost})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].cost != candidates[j].cost {
+			return candidates[i].cost < candidates[j].cost
+		}
+		return candidates[i].word < candidates[j].word
+	})
+	var ret []string
+	for i := 0; i < len(candidates) && (limit <= 0 || i < limit); i++ {
+		ret = append(ret, candidates[i].word)
+	}
+	return ret
+}
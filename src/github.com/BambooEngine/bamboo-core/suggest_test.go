@@ -0,0 +1,88 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"testing"
+)
+
+func inStrings(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSuggest(t *testing.T) {
+	var tests = []struct {
+		word     string
+		expected string
+	}{
+		{"hoà", "hòa"},       // tone move
+		{"viềc", "việc"},     // wrong tone for the last consonant
+		{"thuơng", "thương"}, // mark swap
+		{"đươc", "được"},
+		{"sưa", "xưa"}, // confused consonants
+		{"nghành", "ngành"},
+		{"ngiêng", "nghiêng"},
+		{"trương", "chương"},
+		{"quyen", "quyên"},
+		{"giếng", "giếng"},
+	}
+	for _, test := range tests {
+		if s := Suggest(test.word, 9, true); !inStrings(s, test.expected) {
+			t.Errorf("Suggest %s, expected %s, got %v", test.word, test.expected, s)
+		}
+	}
+	if s := Suggest("hoà", 1, true); len(s) != 1 || s[0] != "hòa" {
+		t.Errorf("Suggest hoà, expected [hòa], got %v", s)
+	}
+	// the new style keeps the tone on the second vowel
+	for _, word := range []string{"hoà", "hòa"} {
+		if s := Suggest(word, 1, false); len(s) != 1 || s[0] != "hoà" {
+			t.Errorf("Suggest %s in the new style, expected [hoà], got %v", word, s)
+		}
+	}
+	if s := Suggest("gìng", 1, false); len(s) != 1 || s[0] != "gìn" {
+		t.Errorf("Suggest gìng in the new style, expected [gìn], got %v", s)
+	}
+	for _, word := range []string{"ca", "khoa", "giêng", "quyết"} {
+		if s := Suggest(word, 1, true); len(s) != 1 || s[0] != word {
+			t.Errorf("Suggest the valid syllable %s, expected itself, got %v", word, s)
+		}
+	}
+	for _, s := range Suggest("cóa", 0, true) {
+		if s == "cóa" || s == "ác" || s == "gìa" {
+			t.Errorf("Suggest cóa, got the invalid syllable %s", s)
+		}
+	}
+}
+
+func TestEngineSuggest(t *testing.T) {
+	ng := newStdEngine().(*BambooEngine)
+	ng.ProcessString("Vieecj", VietnameseMode)
+	if s := ng.Suggest(3); len(s) == 0 || s[0] != "việc" {
+		t.Errorf("Suggest [Vieecj], got %v expected [việc ...]", s)
+	}
+	// the syllable being typed, in the tone style of the engine
+	ng = NewEngine(ParseInputMethod(InputMethodDefinitions, "Telex 2"), EstdFlags&^EstdToneStyle).(*BambooEngine)
+	ng.ProcessString("vieetnhoaf", VietnameseMode)
+	if s := ng.Suggest(1); len(s) != 1 || s[0] != "nhoà" {
+		t.Errorf("Suggest [vieetnhoaf] in the new style, got %v expected [nhoà]", s)
+	}
+}
+
+func BenchmarkSuggest(b *testing.B) {
+	Suggest("", 1, true)
+	for i := 0; i < b.N; i++ {
+		Suggest("nghành", 9, true)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"strings"
+)
+
+// Transliterate converts a text typed with an input method, e.g. "tieengs Vieetj" with Telex, into
+// Vietnamese. Words are broken at spaces, punctuation marks and digits like the IBus engine does,
+// and the words that are not Vietnamese are restored to their key strokes as the policy says.
+func Transliterate(text string, inputMethod InputMethod, flags uint, policy RestorePolicy) string {
+	var c = &WordComposer{
+		Engine: NewEngine(inputMethod, flags),
+		Policy: policy,
+	}
+	return c.Transliterate(text)
+}
+
+// Transliterate converts a text with the engine, the policy and the macros of the composer, the
+// last word of the text is complete like one followed by a space.
+func (c *WordComposer) Transliterate(text string) string {
+	var out strings.Builder
+	var commit = func(s string) {
+		out.WriteString(s)
+		c.Engine.Reset()
+	}
+	for _, key := range text {
+		var oldText = c.PreeditString()
+		if c.Engine.CanProcessKey(key) {
+			if s, ok := c.ProcessKey(key); ok {
+				commit(s)
+			}
+		} else if oldText == "" {
+			out.WriteRune(key)
+		} else if IsWordBreakSymbol(key) {
+			var word, _ = c.CompleteWord()
+			commit(word + string(key))
+		} else {
+			commit(oldText + string(key))
+		}
+	}
+	var word, _ = c.CompleteWord()
+	commit(word)
+	return out.String()
+}
@@ -0,0 +1,67 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	var telex = ParseInputMethod(InputMethodDefinitions, "Telex")
+	var vni = ParseInputMethod(InputMethodDefinitions, "VNI")
+	var restore = RestorePolicy{AutoRestore: true, DdFreeStyle: true}
+	var tests = []struct {
+		im       InputMethod
+		policy   RestorePolicy
+		text     string
+		expected string
+	}{
+		{telex, RestorePolicy{}, "trawm nawm trong coxi nguowfi ta", "trăm năm trong cõi người ta"},
+		{telex, RestorePolicy{}, "Gacs mais, nguw oong veef vieenx xuws.", "Gác mái, ngư ông về viễn xứ."},
+		{telex, RestorePolicy{}, "VIEETJ NAM\nnawm 2024!", "VIỆT NAM\nnăm 2024!"},
+		{telex, RestorePolicy{}, "(ddaauf)", "(đầu)"},
+		{telex, restore, "tooi dungf Windows vaf Linux", "tôi dùng Windows và Linux"},
+		{telex, restore, "ddc ddaayf", "đc đầy"},
+		{telex, RestorePolicy{}, "tooi dungf Windows", "tôi dùng Windớ"},
+		{vni, RestorePolicy{}, "Vie65t Nam5 1999", "Việt Nạm 1999"},
+		{telex, RestorePolicy{}, "", ""},
+		{telex, restore, "ddc tooi", "đc tôi"},
+		{telex, RestorePolicy{AutoRestore: true}, "ddc tooi", "ddc tôi"},
+		// the words out of the dictionary are restored
+		{telex, RestorePolicy{AutoRestore: true, InDictionary: func(w string) bool { return w == "tôi" || w == "dùng" }}, "tooi vaf dungf", "tôi vaf dùng"},
+		{telex, RestorePolicy{PreferEnglish: func(keys, word string) bool { return keys == "box" }}, "box tooi", "box tôi"},
+	}
+	for _, test := range tests {
+		if s := Transliterate(test.text, test.im, EstdFlags, test.policy); s != test.expected {
+			t.Errorf("Transliterate [%s] with %s, got [%s] expected [%s]", test.text, test.im.Name, s, test.expected)
+		}
+	}
+}
+
+func TestTransliterateWithMacros(t *testing.T) {
+	var telex = ParseInputMethod(InputMethodDefinitions, "Telex")
+	var macros = map[string]string{"btw": "by the way", "vn": "Việt Nam"}
+	var tests = []struct {
+		text     string
+		expected string
+	}{
+		{"btw, tooi owr vn", "by the way, tôi ở Việt Nam"},
+		{"vnn vn", "vnn Việt Nam"},
+	}
+	for _, test := range tests {
+		var c = &WordComposer{
+			Engine: NewEngine(telex, EstdFlags),
+			Policy: RestorePolicy{AutoRestore: true, DdFreeStyle: true},
+			Macro:  func(key string) string { return macros[key] },
+		}
+		if s := c.Transliterate(test.text); s != test.expected {
+			t.Errorf("Transliterate [%s] with macros, got [%s] expected [%s]", test.text, s, test.expected)
+		}
+	}
+}
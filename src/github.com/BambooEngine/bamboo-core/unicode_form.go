@@ -0,0 +1,152 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// the combining characters of the Vietnamese letters in the decomposed form (NFD)
+var combiningTones = map[rune]Tone{
+	'\u0300': ToneGrave,
+	'\u0340': ToneGrave, // deprecated, the same as U+0300
+	'\u0301': ToneAcute,
+	'\u0341': ToneAcute, // deprecated, the same as U+0301
+	'\u0309': ToneHook,
+	'\u0303': ToneTilde,
+	'\u0323': ToneDot,
+}
+
+var combiningMarks = map[rune]Mark{
+	'\u0302': MarkHat,
+	'\u0306': MarkBreve,
+	'\u031B': MarkHorn,
+}
+
+var toneCombiningChars = map[Tone]rune{
+	ToneGrave: '\u0300',
+	ToneAcute: '\u0301',
+	ToneHook:  '\u0309',
+	ToneTilde: '\u0303',
+	ToneDot:   '\u0323',
+}
+
+var markCombiningChars = map[Mark]rune{
+	MarkHat:   '\u0302',
+	MarkBreve: '\u0306',
+	MarkHorn:  '\u031B',
+}
+
+// IsCombiningChar tells whether a character is a tone or a mark of a decomposed Vietnamese letter
+func IsCombiningChar(chr rune) bool {
+	if _, found := combiningTones[chr]; found {
+		return true
+	}
+	_, found := combiningMarks[chr]
+	return found
+}
+
+// composeChar puts a combining character on a letter, it fails if the letter has the tone or the
+// mark already or cannot have them, e.g. "ê" has no breve
+func composeChar(chr, combining rune) (rune, bool) {
+	var lower = unicode.ToLower(chr)
+	var composed = lower
+	if tone, found := combiningTones[combining]; found {
+		if !IsVowel(lower) || FindToneFromChar(lower) != ToneNone {
+			return chr, false
+		}
+		composed = AddToneToChar(lower, uint8(tone))
+	} else if mark, found := combiningMarks[combining]; found {
+		var toneless = AddToneToChar(lower, 0)
+		if toneless != AddMarkToTonelessChar(toneless, 0) {
+			return chr, false
+		}
+		composed = AddMarkToChar(lower, uint8(mark))
+	}
+	if composed == lower {
+		return chr, false
+	}
+	if unicode.IsUpper(chr) {
+		return unicode.ToUpper(composed), true
+	}
+	return composed, true
+}
+
+// ComposeNFC turns the decomposed Vietnamese letters of a text into the precomposed ones (NFC),
+// e.g. "e\u0323\u0302" into "ệ". The combining characters which cannot be composed are kept.
+func ComposeNFC(text string) string {
+	var out []rune
+	for _, chr := range text {
+		if len(out) > 0 && IsCombiningChar(chr) {
+			if composed, ok := composeChar(out[len(out)-1], chr); ok {
+				out[len(out)-1] = composed
+				continue
+			}
+		}
+		out = append(out, chr)
+	}
+	return string(out)
+}
+
+// DecomposeNFD turns the precomposed Vietnamese letters of a text into the decomposed ones (NFD),
+// e.g. "ệ" into "e\u0323\u0302". The combining characters come in the canonical order of Unicode,
+// so the dot below goes before the hat and the breve but after the horn. "đ" has no decomposed form.
+func DecomposeNFD(text string) string {
+	var out strings.Builder
+	for _, chr := range text {
+		var lower = unicode.ToLower(chr)
+		if !IsVietnameseRune(lower) || lower == 'đ' {
+			out.WriteRune(chr)
+			continue
+		}
+		var tone = FindToneFromChar(lower)
+		var mark, _ = FindMarkFromChar(AddToneToChar(lower, 0))
+		var base = AddMarkToChar(AddToneToChar(lower, 0), 0)
+		if unicode.IsUpper(chr) {
+			base = unicode.ToUpper(base)
+		}
+		out.WriteRune(base)
+		if tone == ToneDot && mark != MarkHorn {
+			out.WriteRune(toneCombiningChars[tone])
+			tone = ToneNone
+		}
+		if c, found := markCombiningChars[mark]; found {
+			out.WriteRune(c)
+		}
+		if c, found := toneCombiningChars[tone]; found {
+			out.WriteRune(c)
+		}
+	}
+	return out.String()
+}
+
+// composeLastChar puts a combining character on the last character typed, so that a decomposed
+// letter is processed like the precomposed one
+func (e *BambooEngine) composeLastChar(combining rune, mode Mode) bool {
+	var last = len(e.composition) - 1
+	if last < 0 {
+		return false
+	}
+	var trans = e.composition[last]
+	if trans.Rule.EffectType != Appending || trans.Rule.Key == 0 {
+		return false
+	}
+	var chr = []rune(Flatten([]*Transformation{trans}, mode&^(FullText|Decomposed)))
+	if len(chr) != 1 {
+		return false
+	}
+	var composed, ok = composeChar(chr[0], combining)
+	if !ok {
+		return false
+	}
+	e.composition = e.composition[:last]
+	e.ProcessKey(composed, mode)
+	return true
+}
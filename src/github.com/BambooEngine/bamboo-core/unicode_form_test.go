@@ -0,0 +1,99 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComposeNFC(t *testing.T) {
+	var tests = []struct {
+		text     string
+		expected string
+	}{
+		{"Vie\u0323\u0302t Nam", "Việt Nam"},
+		{"VIE\u0302\u0323T", "VIỆT"},
+		{"A\u0323\u0306c", "Ặc"},
+		{"ngu\u031bo\u031b\u0300i", "người"},
+		{"to\u0340i", "tòi"},
+		{"cafe\u0301", "café"},
+		{"e\u0306", "e\u0306"},
+		{"\u0301a", "\u0301a"},
+		{"a\u0301\u0301", "á\u0301"},
+		{"đ\u0302", "đ\u0302"},
+	}
+	for _, test := range tests {
+		if s := ComposeNFC(test.text); s != test.expected {
+			t.Errorf("ComposeNFC %+q, got %+q expected %+q", test.text, s, test.expected)
+		}
+	}
+}
+
+func TestDecomposeNFD(t *testing.T) {
+	var tests = []struct {
+		text     string
+		expected string
+	}{
+		{"Việt Nam", "Vie\u0323\u0302t Nam"},
+		{"Ặc", "A\u0323\u0306c"},
+		{"người", "ngu\u031bo\u031b\u0300i"},
+		{"ợ", "o\u031b\u0323"},
+		{"đá", "đa\u0301"},
+	}
+	for _, test := range tests {
+		if s := DecomposeNFD(test.text); s != test.expected {
+			t.Errorf("DecomposeNFD %+q, got %+q expected %+q", test.text, s, test.expected)
+		}
+	}
+	var letters = string(Vowels) + strings.ToUpper(string(Vowels)) + "đĐ"
+	if s := ComposeNFC(DecomposeNFD(letters)); s != letters {
+		t.Errorf("ComposeNFC(DecomposeNFD(%s)), got [%s]", letters, s)
+	}
+}
+
+func TestProcessDecomposedString(t *testing.T) {
+	var im = ParseInputMethod(InputMethodDefinitions, "Telex")
+	for _, text := range []string{"tiếng Việt", "tiêngs Việt", "ngươì", "Đặng THUỶ", "thuỷ\u0301", "cafés"} {
+		var composed, decomposed = NewEngine(im, EstdFlags), NewEngine(im, EstdFlags)
+		composed.ProcessString(text, VietnameseMode)
+		// composed and decomposed letters are mixed
+		for i, word := range strings.Split(text, " ") {
+			if i%2 == 0 {
+				word = DecomposeNFD(word)
+			}
+			if i > 0 {
+				decomposed.ProcessKey(' ', VietnameseMode)
+			}
+			decomposed.ProcessString(word, VietnameseMode)
+		}
+		for _, mode := range []Mode{VietnameseMode, EnglishMode} {
+			var expected = composed.GetProcessedString(mode | FullText)
+			if s := decomposed.GetProcessedString(mode | FullText); s != expected {
+				t.Errorf("Process decomposed [%s] in mode %d, got %+q expected %+q", text, mode, s, expected)
+			}
+		}
+		var expected = DecomposeNFD(composed.GetProcessedString(VietnameseMode | FullText))
+		if s := decomposed.GetProcessedString(VietnameseMode | FullText | Decomposed); s != expected {
+			t.Errorf("Decomposed output of [%s], got %+q expected %+q", text, s, expected)
+		}
+	}
+	// a composed letter with a combining tone
+	var ng = NewEngine(im, EstdFlags)
+	ng.ProcessString("Vi\u00ea\u0323t", VietnameseMode)
+	if s := ng.GetProcessedString(VietnameseMode); s != "Việt" {
+		t.Errorf("Process %+q, got %+q expected [Việt]", "Vi\u00ea\u0323t", s)
+	}
+}
+
+func TestEncodeNFD(t *testing.T) {
+	if s := Encode(UNICODE_NFD, "Việt"); s != "Vie\u0323\u0302t" {
+		t.Errorf("Encode [Việt] in %s, got %+q", UNICODE_NFD, s)
+	}
+}
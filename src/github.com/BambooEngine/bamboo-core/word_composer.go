@@ -0,0 +1,96 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This software is licensed under the MIT license. For more information,
+ * see <https://github.com/BambooEngine/bamboo-core/blob/master/LICENSE>.
+ */
+
+package bamboo
+
+import (
+	"unicode"
+)
+
+// WordComposer decides what is shown and committed while the words of a text are typed key by
+// key: the IBus engine and Transliterate share it, so they restore the words that are not
+// Vietnamese, commit them at the appending keys and expand the macros the same way.
+type WordComposer struct {
+	Engine IEngine
+	Policy RestorePolicy
+	// Macro, if set, returns the text of the macro named by the key, "" if there is none
+	Macro func(key string) string
+}
+
+// MacroText returns the text of the macro named by the word being typed
+func (c *WordComposer) MacroText() (string, bool) {
+	if c.Macro == nil {
+		return "", false
+	}
+	for _, mode := range []Mode{VietnameseMode, PunctuationMode} {
+		if text := c.Macro(c.Engine.GetProcessedString(mode)); text != "" {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// ShouldRestore tells whether the word being typed is shown with its key strokes, a word that names
+// a macro is kept unless it is an English word
+func (c *WordComposer) ShouldRestore(checkVnRune bool) bool {
+	if _, ok := c.MacroText(); ok && !c.Policy.prefersEnglish(c.Engine) {
+		return false
+	}
+	return c.Policy.ShouldRestore(c.Engine, checkVnRune)
+}
+
+// InputMode is the mode in which the next key is processed
+func (c *WordComposer) InputMode() Mode {
+	if c.ShouldRestore(false) {
+		return EnglishMode
+	}
+	return VietnameseMode
+}
+
+// PreeditString returns the word being typed as it is shown
+func (c *WordComposer) PreeditString() string {
+	if c.ShouldRestore(true) {
+		return c.Engine.GetProcessedString(EnglishMode)
+	}
+	return c.Engine.GetProcessedString(VietnameseMode)
+}
+
+// ComposedString returns the word once it is complete, restored to its key strokes if the policy
+// says so, oldText is the word as it was shown
+func (c *WordComposer) ComposedString(oldText string) string {
+	if HasAnyVietnameseRune(oldText) && c.Policy.MustRestore(c.Engine) {
+		return c.Engine.GetProcessedString(EnglishMode)
+	}
+	return oldText
+}
+
+// CompleteWord returns the text committed for the word being typed at a word break, the text of its
+// macro or the composed string, expanded tells that a macro is expanded
+func (c *WordComposer) CompleteWord() (text string, expanded bool) {
+	if text, ok := c.MacroText(); ok {
+		return text, true
+	}
+	return c.ComposedString(c.PreeditString()), false
+}
+
+// ProcessKey processes a key that the engine can process. An appending key that ends the word, e.g.
+// a bracket typed twice, returns the text to commit and true, the caller resets the engine then.
+func (c *WordComposer) ProcessKey(key rune) (string, bool) {
+	var oldText = c.PreeditString()
+	c.Engine.ProcessKey(key, c.InputMode())
+	if !inKeyList(c.Engine.GetInputMethod().AppendingKeys, unicode.ToLower(key)) {
+		return "", false
+	}
+	if fullSeq := []rune(c.Engine.GetProcessedString(VietnameseMode)); len(fullSeq) > 0 && fullSeq[len(fullSeq)-1] == key {
+		return string(fullSeq), true
+	}
+	if newText := []rune(c.PreeditString()); len(newText) > 0 && newText[len(newText)-1] == key {
+		return oldText + string(key), true
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+// convert transliterates key strokes, given as arguments or read line by line from the input,
+// e.g. `ibus-engine-bamboo convert --im Telex "tieengs Vieetj"`. The input methods, the flags and the
+// dictionaries and the macros are the ones of the config, so a text reads like the one typed in the engine.
+func convert(args []string, c *Config, engineName string, in io.Reader, out io.Writer) error {
+	var fs = flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(out)
+	var imName = fs.String("im", c.InputMethod, "Input method")
+	var restore = fs.Bool("restore", c.IBflags&IBautoNonVnRestore != 0, "Restore the key strokes of non-Vietnamese words")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var inputMethod = bamboo.ParseInputMethod(c.getInputMethodDefinitions(), *imName)
+	if inputMethod.Name == "" {
+		return fmt.Errorf("unknown input method %q", *imName)
+	}
+	// the flag overrides the auto restore of the config
+	var config = *c
+	if *restore {
+		config.IBflags |= IBautoNonVnRestore
+	} else {
+		config.IBflags &^= IBautoNonVnRestore
+	}
+	var inDictionary = func(string) bool { return false }
+	if config.IBflags&(IBspellCheckWithDicts|IBenglishDictEnabled) != 0 {
+		d, err := loadSpellingDictionary(&config, engineName)
+		if err != nil {
+			return err
+		}
+		inDictionary = d.Has
+	}
+	if config.IBflags&IBenglishDictEnabled != 0 && englishDictionary.Len() == 0 {
+		d, err := loadDictionary(DictEnglish)
+		if err != nil {
+			return err
+		}
+		englishDictionary = d
+	}
+	var macro func(string) string
+	if config.IBflags&IBmacroEnabled != 0 {
+		var table = NewMacroTable()
+		if err := table.LoadFromFile(getMactabFile(engineName)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		macro = func(key string) string {
+			var text, _ = table.Lookup(key, "", inputMethod.Name)
+			return expandMacro(text, key, config.IBflags&IBautoCapitalizeMacro != 0)
+		}
+	}
+	var transliterate = func(text string) string {
+		var c = &bamboo.WordComposer{
+			Engine: bamboo.NewEngine(inputMethod, config.Flags),
+			Policy: newRestorePolicy(&config, inDictionary),
+			Macro:  macro,
+		}
+		return c.Transliterate(text)
+	}
+	if fs.NArg() > 0 {
+		_, err := fmt.Fprintln(out, transliterate(strings.Join(fs.Args(), " ")))
+		return err
+	}
+	var scanner = bufio.NewScanner(in)
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(out, transliterate(scanner.Text())); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
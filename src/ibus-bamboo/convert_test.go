@@ -0,0 +1,81 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+func TestConvert(t *testing.T) {
+	var c = &Config{
+		InputMethod:            "Telex",
+		InputMethodDefinitions: bamboo.GetInputMethodDefinitions(),
+		Flags:                  bamboo.EstdFlags,
+		IBflags:                IBstdFlags,
+	}
+	var in = strings.NewReader("trawm nawm trong coxi nguowfi ta\nchuwx taif chuwx meejnh kheso laf ghest nhau\n")
+	var out bytes.Buffer
+	if err := convert(nil, c, "bamboo", in, &out); err != nil {
+		t.Fatal(err)
+	}
+	var expected = "trăm năm trong cõi người ta\nchữ tài chữ mệnh khéo là ghét nhau\n"
+	if out.String() != expected {
+		t.Errorf("Convert stdin, got %q expected %q", out.String(), expected)
+	}
+	out.Reset()
+	if err := convert([]string{"--im", "VNI", "Vie65t", "Nam"}, c, "bamboo", nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Việt Nam\n" {
+		t.Errorf("Convert args, got %q expected %q", out.String(), "Việt Nam\n")
+	}
+	if err := convert([]string{"--im", "Unknown"}, c, "bamboo", nil, &out); err == nil {
+		t.Errorf("Convert with an unknown input method, expected an error")
+	}
+}
+
+func TestConvertWithConfig(t *testing.T) {
+	var c = &Config{
+		InputMethod:            "Telex W",
+		InputMethodDefinitions: bamboo.GetInputMethodDefinitions(),
+		InputMethodFiles:       map[string]bamboo.InputMethodDefinition{"Telex W": {"s": "DauSac", "w": "UOA_ƯƠĂ__Ư"}},
+		Flags:                  bamboo.EstdFlags,
+		IBflags:                IBstdFlags &^ IBddFreeStyle,
+	}
+	var tests = []struct {
+		args     []string
+		expected string
+	}{
+		// the input method of a file is the default one
+		{[]string{"tws"}, "tứ\n"},
+		// dd is restored without IBddFreeStyle
+		{[]string{"--im", "Telex", "ddc", "tooi"}, "ddc tôi\n"},
+		{[]string{"--im", "Telex", "--restore=false", "ddc", "tooi"}, "đc tôi\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := convert(test.args, c, "bamboo", nil, &out); err != nil || out.String() != test.expected {
+			t.Errorf("Convert %v with a config, got %q %v expected %q", test.args, out.String(), err, test.expected)
+		}
+	}
+}
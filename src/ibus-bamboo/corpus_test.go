@@ -0,0 +1,326 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+	"github.com/BambooEngine/goibus/ibus"
+	"github.com/godbus/dbus"
+)
+
+// go test -run TestCorpus -update regenerates the golden files after an intended change
+var updateGolden = flag.Bool("update", false, "Regenerate the golden files of the corpus")
+
+const (
+	corpusFile = "../../tests/xtest.data"
+	// the key strokes of everyday text in Telex: English words, abbreviations such as ddc, mixed
+	// case and punctuation, which the restore and the dd options change. Their golden lines are
+	// checked by hand, e.g. "is" and "meet" stay "í" and "mêt" as both are valid syllables.
+	mixedCorpusFile = "../../tests/xtest-mixed.data"
+	goldenDir       = "../../tests/golden"
+)
+
+// the policy of the core runners that restore the words, without the dictionaries of the IBus engine
+var coreRestorePolicy = bamboo.RestorePolicy{AutoRestore: true, DdFreeStyle: true}
+
+// the corpus is typed in Telex, the other input methods replay the key strokes of the same text
+var corpusRunners = []struct {
+	name string
+	run  func(im bamboo.InputMethod, lines []string) ([]string, error)
+}{
+	{"core", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return transliterateLines(lines, im, bamboo.EstdFlags, bamboo.RestorePolicy{DdFreeStyle: true}), nil
+	}},
+	{"core-restore", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return transliterateLines(lines, im, bamboo.EstdFlags, coreRestorePolicy), nil
+	}},
+	{"core-old-tone-style", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return transliterateLines(lines, im, bamboo.EstdFlags&^bamboo.EstdToneStyle, bamboo.RestorePolicy{DdFreeStyle: true}), nil
+	}},
+	{"core-no-free-marking", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return transliterateLines(lines, im, bamboo.EstdFlags&^bamboo.EfreeToneMarking, coreRestorePolicy), nil
+	}},
+	{"ibus", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return typeLines(lines, im, bamboo.EstdFlags, IBstdFlags&^IBmouseCapturing)
+	}},
+	{"ibus-no-restore", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return typeLines(lines, im, bamboo.EstdFlags, IBstdFlags&^IBmouseCapturing&^IBautoNonVnRestore)
+	}},
+	{"ibus-no-dd-free-style", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return typeLines(lines, im, bamboo.EstdFlags, IBstdFlags&^IBmouseCapturing&^IBddFreeStyle)
+	}},
+	{"ibus-old-tone-style", func(im bamboo.InputMethod, lines []string) ([]string, error) {
+		return typeLines(lines, im, bamboo.EstdFlags&^bamboo.EstdToneStyle, IBstdFlags&^IBmouseCapturing)
+	}},
+}
+
+var regNonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+// goldenPath names the golden file of an input method in ASCII, e.g. vni-ban-phim-tieng-phap.txt
+func goldenPath(imName string) string {
+	var name = []rune(strings.ToLower(imName))
+	for i, c := range name {
+		name[i] = bamboo.AddMarkToTonelessChar(bamboo.AddToneToChar(c, 0), 0)
+	}
+	return filepath.Join(goldenDir, strings.Trim(regNonAlnum.ReplaceAllString(string(name), "-"), "-")+".txt")
+}
+
+func transliterateLines(lines []string, im bamboo.InputMethod, flags uint, policy bamboo.RestorePolicy) []string {
+	var out []string
+	for _, line := range lines {
+		out = append(out, bamboo.Transliterate(line, im, flags, policy))
+	}
+	return out
+}
+
+func readLines(path string) ([]string, error) {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+// corpusKeys types the text of the Telex corpus with another input method, a line that the input
+// method cannot type fails the update rather than keeping the Telex keys
+func corpusKeys(im bamboo.InputMethod) ([]string, error) {
+	var lines, err = readLines(corpusFile)
+	if err != nil {
+		return nil, err
+	}
+	if im.Name == "Telex" {
+		// the English words of the mixed corpus have no key strokes in the other input methods
+		var mixed, err = readLines(mixedCorpusFile)
+		return append(lines, mixed...), err
+	}
+	var telex = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	for i, line := range lines {
+		var text = bamboo.Transliterate(line, telex, bamboo.EstdFlags, bamboo.RestorePolicy{DdFreeStyle: true})
+		var keys, err = bamboo.Keystrokes(text, im, bamboo.EstdFlags)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", corpusFile, i+1, err)
+		}
+		lines[i] = keys
+	}
+	return lines, nil
+}
+
+// A golden file has a line of "runner<TAB>keys<TAB>text" for each line of the corpus and runner
+func TestCorpus(t *testing.T) {
+	for name := range bamboo.GetInputMethodDefinitions() {
+		var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), name)
+		var path = goldenPath(name)
+		if *updateGolden {
+			var keys, err = corpusKeys(im)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			for _, runner := range corpusRunners {
+				var out, err = runner.run(im, keys)
+				if err != nil {
+					t.Fatalf("%s with %s: %v", runner.name, name, err)
+				}
+				for i := range keys {
+					fmt.Fprintf(&buf, "%s\t%s\t%s\n", runner.name, keys[i], out[i])
+				}
+			}
+			if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		var golden, err = readLines(path)
+		if err != nil {
+			t.Fatalf("%v, run go test -run TestCorpus -update to create it", err)
+		}
+		for _, runner := range corpusRunners {
+			var keys, expected []string
+			for _, line := range golden {
+				var fields = strings.Split(line, "\t")
+				if len(fields) == 3 && fields[0] == runner.name {
+					keys = append(keys, fields[1])
+					expected = append(expected, fields[2])
+				}
+			}
+			var out, err = runner.run(im, keys)
+			if err != nil {
+				t.Fatalf("%s with %s: %v", runner.name, name, err)
+			}
+			for i := range keys {
+				if out[i] != expected[i] {
+					t.Errorf("%s with %s [%s], got [%s] expected [%s]", runner.name, name, keys[i], out[i], expected[i])
+				}
+			}
+		}
+	}
+}
+
+// signalRecorder is a fake IBus daemon on the other end of the engine's D-Bus connection, it keeps
+// the text that the engine commits to the application and the preedit text
+type signalRecorder struct {
+	conn        *dbus.Conn
+	synced      chan struct{}
+	committed   strings.Builder
+	preedit     string
+	preeditMode uint32
+	deleted     [2]int64 // the offset from the cursor and the number of deleted characters
+	text        []rune   // the text before the cursor in the application, with the commits and the deletions
+	aux         string
+}
+
+const recorderPath = dbus.ObjectPath("/org/freedesktop/IBus/Engine/Recorder")
+
+func newSignalRecorder() (*signalRecorder, error) {
+	var client, server = net.Pipe()
+	var r = &signalRecorder{synced: make(chan struct{})}
+	go r.serve(server)
+	var conn, err = dbus.NewConn(client)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Auth([]dbus.Auth{dbus.AuthExternal("0")}); err != nil {
+		return nil, err
+	}
+	r.conn = conn
+	return r, nil
+}
+
+func (r *signalRecorder) serve(server io.ReadWriter) {
+	var in = bufio.NewReader(server)
+	if _, err := in.ReadByte(); err != nil {
+		return
+	}
+	for {
+		var line, err = in.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "AUTH EXTERNAL"):
+			io.WriteString(server, "OK 0123456789abcdef0123456789abcdef\r\n")
+		case strings.HasPrefix(line, "AUTH"):
+			io.WriteString(server, "REJECTED EXTERNAL\r\n")
+		case strings.HasPrefix(line, "BEGIN"):
+			r.record(in)
+			return
+		}
+	}
+}
+
+func (r *signalRecorder) record(in io.Reader) {
+	for {
+		var msg, err = dbus.DecodeMessage(in)
+		if err != nil {
+			return
+		}
+		switch msg.Headers[dbus.FieldMember].Value() {
+		case "CommitText":
+			var text = msg.Body[0].(dbus.Variant).Value().([]interface{})
+			r.committed.WriteString(text[2].(string))
+			r.text = append(r.text, []rune(text[2].(string))...)
+		case "UpdatePreeditText":
+			r.preedit = msg.Body[0].(dbus.Variant).Value().([]interface{})[2].(string)
+			r.preeditMode = msg.Body[3].(uint32)
+		case "HidePreeditText":
+			r.preedit = ""
+		case "UpdateAuxiliaryText":
+			r.aux = msg.Body[0].(dbus.Variant).Value().([]interface{})[2].(string)
+		case "HideAuxiliaryText":
+			r.aux = ""
+		case "DeleteSurroundingText":
+			r.deleted = [2]int64{int64(msg.Body[0].(int32)), int64(msg.Body[1].(uint32))}
+			if start, end := len(r.text)+int(r.deleted[0]), len(r.text)+int(r.deleted[0])+int(r.deleted[1]); start >= 0 && end <= len(r.text) {
+				r.text = append(r.text[:start], r.text[end:]...)
+			}
+		case "Sync":
+			r.synced <- struct{}{}
+		}
+	}
+}
+
+// sync waits for the signals that the engine has emitted so far
+func (r *signalRecorder) sync() {
+	r.conn.Emit(recorderPath, "org.freedesktop.IBus.Recorder.Sync")
+	<-r.synced
+}
+
+// typeLines types every line in an IBus engine then presses Enter, the keys that the engine does not
+// handle are typed in the application
+func typeLines(lines []string, im bamboo.InputMethod, flags, ibFlags uint) ([]string, error) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		return nil, err
+	}
+	defer r.conn.Close()
+	var engine = newTestEngine(r, im, ibFlags)
+	engine.config.Flags = flags
+	engine.preeditor = newPreeditor(engine.config, engine.engineName)
+	var out []string
+	for _, line := range lines {
+		for _, key := range line + "\r" {
+			var keyVal = uint32(key)
+			if key == '\r' {
+				keyVal = IBusReturn
+			}
+			var handled, _ = engine.ProcessKeyEvent(keyVal, 0, 0)
+			r.sync()
+			if !handled && key != '\r' {
+				r.committed.WriteRune(key)
+			}
+		}
+		out = append(out, r.committed.String())
+		r.committed.Reset()
+	}
+	return out, nil
+}
+
+// newTestEngine creates an IBus engine with the default config, which emits its signals to r
+func newTestEngine(r *signalRecorder, im bamboo.InputMethod, ibFlags uint) *IBusBambooEngine {
+	var config = &Config{
+		InputMethod:            im.Name,
+		OutputCharset:          "Unicode",
+		InputMethodDefinitions: bamboo.GetInputMethodDefinitions(),
+		Flags:                  bamboo.EstdFlags,
+		IBflags:                ibFlags,
+		DefaultInputMode:       preeditIM,
+		InputModeMapping:       map[string]int{},
+	}
+	var engine = &IBusBambooEngine{
+		Engine:     ibus.BaseEngine(r.conn, recorderPath),
+		engineName: "bamboo",
+		config:     config,
+		preeditor:  newPreeditor(config, "bamboo"),
+		macroTable: NewMacroTable(),
+		propList:   GetPropListByConfig(config),
+	}
+	return engine
+}
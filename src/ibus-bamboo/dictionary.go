@@ -0,0 +1,251 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+// the key modes that a Dictionary is indexed by
+var dictionaryKeyModes = []bamboo.Mode{
+	bamboo.ToneLess,
+	bamboo.MarkLess,
+	bamboo.ToneLess | bamboo.MarkLess,
+}
+
+// wordList is a sorted list of strings stored in one string, which costs far less memory than
+// a map[string]bool with a header per word.
+type wordList struct {
+	data    string
+	offsets []uint32 // word i is data[offsets[i]:offsets[i+1]]
+}
+
+func newWordList(sortedWords []string) wordList {
+	var b strings.Builder
+	var offsets = make([]uint32, 0, len(sortedWords)+1)
+	for _, w := range sortedWords {
+		offsets = append(offsets, uint32(b.Len()))
+		b.WriteString(w)
+	}
+	offsets = append(offsets, uint32(b.Len()))
+	return wordList{data: b.String(), offsets: offsets}
+}
+
+func (l *wordList) Len() int {
+	if len(l.offsets) == 0 {
+		return 0
+	}
+	return len(l.offsets) - 1
+}
+
+func (l *wordList) At(i int) string {
+	return l.data[l.offsets[i]:l.offsets[i+1]]
+}
+
+// search returns the index of the first word that is not less than s
+func (l *wordList) search(s string) int {
+	return sort.Search(l.Len(), func(i int) bool {
+		return l.At(i) >= s
+	})
+}
+
+func (l *wordList) Has(s string) bool {
+	var i = l.search(s)
+	return i < l.Len() && l.At(i) == s
+}
+
+// keyIndex maps the keys of a mode, e.g. "viet" for bamboo.ToneLess|bamboo.MarkLess, to the words
+// that have them: the words of key i are words[starts[i]:starts[i+1]].
+type keyIndex struct {
+	keys   wordList
+	starts []uint32
+	words  []uint32
+}
+
+func newKeyIndex(words *wordList, mode bamboo.Mode) *keyIndex {
+	var n = words.Len()
+	var order = make([]uint32, n)
+	var keys = make([]string, n)
+	for i := 0; i < n; i++ {
+		order[i] = uint32(i)
+		keys[i] = dictionaryKey(words.At(i), mode)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return keys[order[i]] < keys[order[j]]
+	})
+	var idx = &keyIndex{words: order}
+	var uniqueKeys []string
+	for i, w := range order {
+		if i == 0 || keys[w] != keys[order[i-1]] {
+			uniqueKeys = append(uniqueKeys, keys[w])
+			idx.starts = append(idx.starts, uint32(i))
+		}
+	}
+	idx.starts = append(idx.starts, uint32(n))
+	idx.keys = newWordList(uniqueKeys)
+	return idx
+}
+
+// dictionaryKey flattens a word the way bamboo.Flatten does with the ToneLess and MarkLess modes.
+func dictionaryKey(word string, mode bamboo.Mode) string {
+	var runes = []rune(strings.ToLower(word))
+	for i, c := range runes {
+		if mode&bamboo.ToneLess != 0 {
+			c = bamboo.AddToneToChar(c, 0)
+		}
+		if mode&bamboo.MarkLess != 0 {
+			c = bamboo.AddMarkToChar(c, 0)
+		}
+		runes[i] = c
+	}
+	return string(runes)
+}
+
+// Dictionary is a set of lowercase words merged from several files, e.g. the system dictionary,
+// dictionaries shared by a team and the personal dictionary. A file takes precedence over the files
+// loaded before it: its "!word" lines remove the words that they added. Words can also be looked
+// up by their forms without tones and/or marks, so spell checking and suggestions share one copy.
+type Dictionary struct {
+	words   wordList
+	indexes map[bamboo.Mode]*keyIndex
+	added   map[string]bool // the words added at runtime, e.g. learned ones
+}
+
+func NewDictionary(words []string) *Dictionary {
+	var set = map[string]bool{}
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			set[w] = true
+		}
+	}
+	var sorted = make([]string, 0, len(set))
+	for w := range set {
+		sorted = append(sorted, w)
+	}
+	sort.Strings(sorted)
+	var d = &Dictionary{
+		words:   newWordList(sorted),
+		indexes: map[bamboo.Mode]*keyIndex{},
+		added:   map[string]bool{},
+	}
+	for _, mode := range dictionaryKeyModes {
+		d.indexes[mode] = newKeyIndex(&d.words, mode)
+	}
+	return d
+}
+
+func (d *Dictionary) Len() int {
+	return d.words.Len() + len(d.added)
+}
+
+func (d *Dictionary) Has(word string) bool {
+	word = strings.ToLower(word)
+	return d.words.Has(word) || d.added[word]
+}
+
+// Add inserts a word without rebuilding the compact storage.
+func (d *Dictionary) Add(word string) {
+	if word = strings.ToLower(strings.TrimSpace(word)); word != "" && !d.words.Has(word) {
+		d.added[word] = true
+	}
+}
+
+// FindByKey returns the words whose key in mode is key, e.g. [việt viết viet...] for "viet"
+// with bamboo.ToneLess|bamboo.MarkLess.
+func (d *Dictionary) FindByKey(key string, mode bamboo.Mode) []string {
+	return d.find(key, mode, false)
+}
+
+// FindByKeyPrefix returns the words whose key in mode starts with prefix, in key order.
+func (d *Dictionary) FindByKeyPrefix(prefix string, mode bamboo.Mode) []string {
+	return d.find(prefix, mode, true)
+}
+
+func (d *Dictionary) find(key string, mode bamboo.Mode, isPrefix bool) []string {
+	mode &= bamboo.ToneLess | bamboo.MarkLess
+	var match = func(k string) bool {
+		if isPrefix {
+			return strings.HasPrefix(k, key)
+		}
+		return k == key
+	}
+	var result []string
+	if idx := d.indexes[mode]; idx != nil {
+		for i := idx.keys.search(key); i < idx.keys.Len() && match(idx.keys.At(i)); i++ {
+			for _, w := range idx.words[idx.starts[i]:idx.starts[i+1]] {
+				result = append(result, d.words.At(int(w)))
+			}
+		}
+	} else {
+		for i := d.words.search(key); i < d.words.Len() && match(d.words.At(i)); i++ {
+			result = append(result, d.words.At(i))
+		}
+	}
+	var added []string
+	for w := range d.added {
+		if match(dictionaryKey(w, mode)) {
+			added = append(added, w)
+		}
+	}
+	sort.Strings(added)
+	return append(result, added...)
+}
+
+// loadDictionary merges dictionary files, one word per line; the later files take precedence.
+// The optional files, e.g. the team and personal dictionaries, are skipped if they are missing,
+// the first file is not.
+func loadDictionary(dataFile string, optionalFiles ...string) (*Dictionary, error) {
+	var words = map[string]bool{}
+	for i, dataFile := range append([]string{dataFile}, optionalFiles...) {
+		f, err := os.Open(dataFile)
+		if i > 0 && os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var scanner = bufio.NewScanner(f)
+		for scanner.Scan() {
+			var line = strings.ToLower(strings.TrimFunc(scanner.Text(), unicode.IsSpace))
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if strings.HasPrefix(line, "!") {
+				delete(words, line[1:])
+			} else {
+				words[line] = true
+			}
+		}
+		f.Close()
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	var list = make([]string, 0, len(words))
+	for w := range words {
+		list = append(list, w)
+	}
+	return NewDictionary(list), nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+func TestDictionaryFindByKey(t *testing.T) {
+	var d = NewDictionary([]string{"Việt", "viết", "viện", "viet", "vui", "nam"})
+	if d.Len() != 6 || !d.Has("VIỆT") || d.Has("viêt") {
+		t.Errorf("NewDictionary, expected 6 words with việt and without viêt, got %d", d.Len())
+	}
+	var tests = []struct {
+		key    string
+		mode   bamboo.Mode
+		prefix bool
+		words  []string
+	}{
+		{"viet", bamboo.ToneLess | bamboo.MarkLess, false, []string{"viet", "viết", "việt"}},
+		{"viêt", bamboo.ToneLess, false, []string{"viết", "việt"}},
+		{"viẹt", bamboo.MarkLess, false, []string{"việt"}},
+		{"vi", bamboo.ToneLess | bamboo.MarkLess, true, []string{"viện", "viet", "viết", "việt"}},
+		{"vi", 0, true, []string{"viet", "viết", "viện", "việt"}},
+		{"x", bamboo.ToneLess | bamboo.MarkLess, true, nil},
+	}
+	for _, test := range tests {
+		var words []string
+		if test.prefix {
+			words = d.FindByKeyPrefix(test.key, test.mode)
+		} else {
+			words = d.FindByKey(test.key, test.mode)
+		}
+		if !reflect.DeepEqual(words, test.words) {
+			t.Errorf("Find %s (mode %d), expected %v, got %v", test.key, test.mode, test.words, words)
+		}
+	}
+	d.Add("Viếu")
+	if !d.Has("viếu") || d.Len() != 7 {
+		t.Errorf("Add viếu, expected the word to be found")
+	}
+	if words := d.FindByKeyPrefix("vie", bamboo.ToneLess|bamboo.MarkLess); len(words) != 5 || words[4] != "viếu" {
+		t.Errorf("Find vie after adding viếu, got %v", words)
+	}
+}
+
+func TestLoadDictionaryPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ibus-bamboo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var system, team, personal = filepath.Join(dir, "system"), filepath.Join(dir, "team"), filepath.Join(dir, "personal")
+	ioutil.WriteFile(system, []byte("việt\nnam\nkhông\n"), 0644)
+	ioutil.WriteFile(team, []byte("# team words\n!không\nôkê\n"), 0644)
+	ioutil.WriteFile(personal, []byte("Không\n\n"), 0644)
+	d, err := loadDictionary(system, team, filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 3 || !d.Has("ôkê") || d.Has("không") || d.Has("# team words") {
+		t.Errorf("Load the system and team dictionaries, expected [nam việt ôkê], got %d words", d.Len())
+	}
+	if d, _ = loadDictionary(system, team, personal); !d.Has("không") {
+		t.Errorf("Load the personal dictionary last, expected không to be added back")
+	}
+	if _, err = loadDictionary(filepath.Join(dir, "missing"), team); err == nil {
+		t.Errorf("Load a missing system dictionary, expected an error")
+	}
+}
+
+func BenchmarkDictionaryHas(b *testing.B) {
+	d, err := loadDictionary("../../" + DictVietnameseCm)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		d.Has("nghiêng")
+	}
+}
@@ -20,47 +20,332 @@
 package main
 
 import (
-	"encoding/json"
+	"bufio"
+	"encoding/xml"
 	"io/ioutil"
+	"log"
+	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 )
 
-type EmojiOne struct {
-	Name      string
-	Shortname string
-	Keywords  []string
-	ASCII     []string
+type Emoji struct {
+	Sequence string // fully-qualified code point sequence
+	Name     string
+	Group    string
+	Subgroup string
+	Version  string // the Emoji version that introduced the sequence, e.g. "13.0"
+	Tone     int    // the Fitzpatrick modifier: 0 if none, 1 (light) to 5 (dark), -1 if mixed
+	group    string
 }
 
-func loadEmojiOne(dataFile string) (*TrieNode, error) {
-	var trie = NewTrie()
-	var c = map[string]EmojiOne{}
-	var data, err = ioutil.ReadFile(dataFile)
+type EmojiTable struct {
+	Version  string // the version of emoji-test.txt, e.g. "15.1"
+	Emojis   []*Emoji
+	trie     *Trie
+	index    map[string]*Emoji
+	groups   map[string][]*Emoji
+	keywords map[string][]string // the words of the keywords of every emoji, for fuzzy search
+}
+
+func NewEmojiTable() *EmojiTable {
+	return &EmojiTable{
+		trie:     NewTrie(),
+		index:    map[string]*Emoji{},
+		groups:   map[string][]*Emoji{},
+		keywords: map[string][]string{},
+	}
+}
+
+func (t *EmojiTable) IsEmpty() bool {
+	return t.trie.IsEmpty()
+}
+
+// Lookup finds an emoji by its code points, with or without variation selectors.
+func (t *EmojiTable) Lookup(seq string) *Emoji {
+	return t.index[stripVariationSelectors(seq)]
+}
+
+// Variants returns the emoji that share the base of seq, i.e. its skin tone and
+// gender variants, in the emoji-test.txt order (the base emoji comes first).
+func (t *EmojiTable) Variants(seq string) []*Emoji {
+	if emoji := t.Lookup(seq); emoji != nil {
+		return t.groups[emoji.group]
+	}
+	return nil
+}
+
+// WithSkinTone returns the variant of seq that has the given skin tone and the same gender,
+// or seq itself if there's no such variant.
+func (t *EmojiTable) WithSkinTone(seq string, tone int) string {
+	var emoji = t.Lookup(seq)
+	if emoji == nil || emoji.Tone == tone {
+		return seq
+	}
+	var key = stripSkinTones(stripVariationSelectors(seq))
+	for _, variant := range t.groups[emoji.group] {
+		if variant.Tone == tone && stripSkinTones(stripVariationSelectors(variant.Sequence)) == key {
+			return variant.Sequence
+		}
+	}
+	return seq
+}
+
+func (t *EmojiTable) insert(keyword, seq string) {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	if keyword == "" {
+		return
+	}
+	t.trie.Insert(keyword, seq)
+	var words = strings.Fields(keyword)
+	t.addKeywords(seq, words)
+	if len(words) > 1 {
+		for _, word := range words {
+			t.trie.Insert(word, seq)
+		}
+		t.trie.Insert(strings.Join(words, "_"), seq)
+	}
+	// Vietnamese keywords can be searched without diacritics, e.g. cuoi for cười
+	if ascii := removeVietnameseAccents(keyword); ascii != keyword {
+		t.insert(ascii, seq)
+	}
+}
+
+func (t *EmojiTable) addKeywords(seq string, words []string) {
+	for _, word := range words {
+		var found = false
+		for _, w := range t.keywords[seq] {
+			if w == word {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.keywords[seq] = append(t.keywords[seq], word)
+		}
+	}
+}
+
+// FuzzyFind returns the emoji whose keywords match every word of the query, the best matches first.
+// Emoji with the same score keep the emoji-test.txt order, so the result is stable.
+func (t *EmojiTable) FuzzyFind(query string) []string {
+	var tokens = fuzzyTokens(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+	var matches []string
+	var scores = map[string]int{}
+	var cache = map[string]int{}
+	for _, emoji := range t.Emojis {
+		var words = t.keywords[emoji.Sequence]
+		if len(words) == 0 {
+			continue
+		}
+		if score := fuzzyScore(tokens, words, cache); score > 0 {
+			matches = append(matches, emoji.Sequence)
+			scores[emoji.Sequence] = score
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i]] > scores[matches[j]]
+	})
+	return matches
+}
+
+func stripVariationSelectors(seq string) string {
+	return strings.Replace(seq, "\uFE0F", "", -1)
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func stripSkinTones(seq string) string {
+	var runes []rune
+	for _, r := range seq {
+		if !isSkinTone(r) {
+			runes = append(runes, r)
+		}
+	}
+	return string(runes)
+}
+
+// parseEmojiVariant finds the group key and the skin tone of an emoji sequence. The group key is the
+// sequence without skin tone modifiers, variation selectors and a trailing ZWJ + ♀/♂ sign, so
+// 🏃🏽‍♀️ (woman running: medium skin tone) belongs to the group of 🏃 (person running).
+func parseEmojiVariant(seq string) (string, int) {
+	var tone = 0
+	for _, r := range seq {
+		if isSkinTone(r) {
+			if t := int(r-0x1F3FB) + 1; tone == 0 || tone == t {
+				tone = t
+			} else {
+				tone = -1
+			}
+		}
+	}
+	var key = stripSkinTones(stripVariationSelectors(seq))
+	for _, suffix := range []string{"\u200D\u2640", "\u200D\u2642"} {
+		if strings.HasSuffix(key, suffix) {
+			key = strings.TrimSuffix(key, suffix)
+		}
+	}
+	return key, tone
+}
+
+func parseCodePoints(s string, sep string) string {
+	var seq string
+	for _, codePoint := range strings.Split(s, sep) {
+		if code, err := strconv.ParseInt(strings.TrimSpace(codePoint), 16, 32); err == nil {
+			seq += string(rune(code))
+		}
+	}
+	return seq
+}
+
+var regEmojiTestLine = regexp.MustCompile(`^([0-9A-Fa-f ]+?)\s*;\s*([\w-]+)\s*#\s*\S+\s+E(\d+\.\d+)\s+(.+)$`)
+
+// loadEmojiTest reads the fully-qualified emoji of a Unicode emoji-test.txt file.
+func loadEmojiTest(t *EmojiTable, dataFile string) error {
+	f, err := os.Open(dataFile)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	json.Unmarshal(data, &c)
-	for k, v := range c {
-		var codePointStr string
-		for _, codePoint := range strings.Split(k, "-") {
-			if code, err := strconv.ParseInt(codePoint, 16, 32); err == nil {
-				codePointStr += string(rune(code))
+	defer f.Close()
+	var group, subgroup string
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			var comment = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			if strings.HasPrefix(comment, "Version:") {
+				t.Version = strings.TrimSpace(strings.TrimPrefix(comment, "Version:"))
+			} else if strings.HasPrefix(comment, "group:") {
+				group = strings.TrimSpace(strings.TrimPrefix(comment, "group:"))
+			} else if strings.HasPrefix(comment, "subgroup:") {
+				subgroup = strings.TrimSpace(strings.TrimPrefix(comment, "subgroup:"))
 			}
+			continue
 		}
-		for _, ascii := range v.ASCII {
-			InsertTrie(trie, ascii, codePointStr)
+		var parts = regEmojiTestLine.FindStringSubmatch(line)
+		if parts == nil || parts[2] != "fully-qualified" {
+			continue
 		}
-		for _, keyword := range v.Keywords {
-			InsertTrie(trie, keyword, codePointStr)
+		var emoji = &Emoji{
+			Sequence: parseCodePoints(parts[1], " "),
+			Name:     parts[4],
+			Group:    group,
+			Subgroup: subgroup,
+			Version:  parts[3],
 		}
+		emoji.group, emoji.Tone = parseEmojiVariant(emoji.Sequence)
+		t.Emojis = append(t.Emojis, emoji)
+		t.index[stripVariationSelectors(emoji.Sequence)] = emoji
+		t.groups[emoji.group] = append(t.groups[emoji.group], emoji)
 	}
-	return trie, nil
+	return scanner.Err()
+}
+
+type emojiAnnotation struct {
+	Cp    string `xml:"cp,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type annotationLdml struct {
+	Annotations []emojiAnnotation `xml:"annotations>annotation"`
+}
+
+// loadAnnotations indexes the keywords and the tts names of an annotations file in the LDML
+// format, e.g. data/annotations/vi.xml or common/annotations/<lang>.xml of a CLDR release.
+// Annotations are usually written without variation selectors, so they get resolved to the
+// fully-qualified sequences of emoji-test.txt.
+func loadAnnotations(t *EmojiTable, dataFile string) error {
+	var data, err = ioutil.ReadFile(dataFile)
+	if err != nil {
+		return err
+	}
+	var ldml annotationLdml
+	if err = xml.Unmarshal(data, &ldml); err != nil {
+		return err
+	}
+	for _, annotation := range ldml.Annotations {
+		var seq = annotation.Cp
+		if emoji := t.Lookup(seq); emoji != nil {
+			seq = emoji.Sequence
+		}
+		if annotation.Type == "tts" {
+			t.insert(annotation.Value, seq)
+			continue
+		}
+		for _, keyword := range strings.Split(annotation.Value, "|") {
+			t.insert(keyword, seq)
+		}
+	}
+	return nil
+}
+
+// loadEmoticons reads `emoticon<TAB>code points` lines, e.g. ":')	1F602".
+func loadEmoticons(t *EmojiTable, dataFile string) error {
+	f, err := os.Open(dataFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if line == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		var parts = strings.Split(line, "\t")
+		if len(parts) != 2 {
+			continue
+		}
+		t.trie.Insert(parts[0], parseCodePoints(parts[1], " "))
+	}
+	return scanner.Err()
+}
+
+func loadEmojiTable(emojiTestFile, emoticonsFile string, annotationFiles ...string) (*EmojiTable, error) {
+	var t = NewEmojiTable()
+	if err := loadEmojiTest(t, emojiTestFile); err != nil {
+		return nil, err
+	}
+	for _, annotationFile := range annotationFiles {
+		if err := loadAnnotations(t, annotationFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadEmoticons(t, emoticonsFile); err != nil {
+		return nil, err
+	}
+	t.trie.Build()
+	return t, nil
+}
+
+func loadEmojis() {
+	var t, err = loadEmojiTable(DictEmojiTest, DictEmoticons, DictEmojiAnnotations...)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	emojiTable = t
 }
 
 type EmojiEngine struct {
-	keys []rune
+	keys       []rune
+	candidates []string
+	queried    bool
+	SkinTone   int
+	History    *EmojiHistory
+
+	// the fuzzy matches of the last string, MatchString and Filter ask for the same keys
+	fuzzyTable   *EmojiTable
+	fuzzyString  string
+	fuzzyMatches []string
 }
 
 func NewEmojiEngine() *EmojiEngine {
@@ -69,31 +354,55 @@ func NewEmojiEngine() *EmojiEngine {
 }
 
 func (be *EmojiEngine) MatchString(s string) bool {
-	var lookup = FindPrefix(emojiTrie, s)
-	return lookup != nil
+	return emojiTable.trie.HasPrefix(s) || len(be.fuzzyFind(s)) > 0
+}
+
+// fuzzyFind goes through the whole emoji table, so its result is kept for the next call
+func (be *EmojiEngine) fuzzyFind(s string) []string {
+	if be.fuzzyTable != emojiTable || be.fuzzyString != s {
+		be.fuzzyTable, be.fuzzyString, be.fuzzyMatches = emojiTable, s, emojiTable.FuzzyFind(s)
+	}
+	return be.fuzzyMatches
 }
 
 func (be *EmojiEngine) Filter(s string) []string {
-	var codePoints []string
-	var keys []string
-	var lookup = FindPrefix(emojiTrie, s)
-	for key := range lookup {
-		keys = append(keys, key)
+	var codePoints = emojiTable.trie.FindPrefix(s)
+	// fall back to fuzzy matching if the prefix matches don't fill a page
+	if len(codePoints) < EmojiMaxPageSize {
+		codePoints = append(codePoints, be.fuzzyFind(s)...)
 	}
-	var names = byString(keys)
-	sort.Sort(names)
-	for _, name := range names {
-		var cps = byString(strings.Split(lookup[name], ":"))
-		sort.Sort(cps)
-		for _, cp := range cps {
-			codePoints = append(codePoints, cp)
-		}
+	codePoints = groupEmojiVariants(codePoints)
+	if be.History != nil {
+		codePoints = be.History.Rank(codePoints)
 	}
 	return codePoints
 }
 
+// groupEmojiVariants removes duplicates and the variants whose base emoji is also
+// a candidate; those variants are reachable through CycleVariant.
+func groupEmojiVariants(codePoints []string) []string {
+	var result []string
+	var found = map[string]bool{}
+	for _, cp := range codePoints {
+		found[cp] = true
+	}
+	var seen = map[string]bool{}
+	for _, cp := range codePoints {
+		if seen[cp] {
+			continue
+		}
+		seen[cp] = true
+		if variants := emojiTable.Variants(cp); len(variants) > 1 && variants[0].Sequence != cp && found[variants[0].Sequence] {
+			continue
+		}
+		result = append(result, cp)
+	}
+	return result
+}
+
 func (be *EmojiEngine) ProcessKey(key rune) {
 	be.keys = append(be.keys, key)
+	be.queried = false
 }
 
 func (be *EmojiEngine) GetRawString() string {
@@ -102,10 +411,62 @@ func (be *EmojiEngine) GetRawString() string {
 
 func (be *EmojiEngine) Reset() {
 	be.keys = nil
+	be.queried = false
 }
 
 func (be *EmojiEngine) Query() []string {
-	return be.Filter(string(be.keys))
+	if !be.queried {
+		be.candidates = be.Filter(string(be.keys))
+		if be.SkinTone != 0 {
+			for i, cp := range be.candidates {
+				be.candidates[i] = emojiTable.WithSkinTone(cp, be.SkinTone)
+			}
+		}
+		if raw := string(be.keys); (raw == "" || raw == ":") && be.History != nil {
+			be.candidates = prependRecentEmojis(be.History.Recent(EmojiMaxPageSize), be.candidates)
+		}
+		be.queried = true
+	}
+	return be.candidates
+}
+
+// prependRecentEmojis shows the recently used emoji on the first page of an empty query.
+func prependRecentEmojis(recent, candidates []string) []string {
+	if len(recent) == 0 {
+		return candidates
+	}
+	var seen = map[string]bool{}
+	for _, cp := range recent {
+		seen[cp] = true
+	}
+	var result = append([]string{}, recent...)
+	for _, cp := range candidates {
+		if !seen[cp] {
+			result = append(result, cp)
+		}
+	}
+	return result
+}
+
+// CycleVariant replaces the candidate at idx with its next skin tone or gender variant.
+func (be *EmojiEngine) CycleVariant(idx int) (string, bool) {
+	var candidates = be.Query()
+	if idx < 0 || idx >= len(candidates) {
+		return "", false
+	}
+	var variants = emojiTable.Variants(candidates[idx])
+	if len(variants) <= 1 {
+		return candidates[idx], false
+	}
+	var next = variants[0]
+	for i, variant := range variants {
+		if stripVariationSelectors(variant.Sequence) == stripVariationSelectors(candidates[idx]) {
+			next = variants[(i+1)%len(variants)]
+			break
+		}
+	}
+	candidates[idx] = next.Sequence
+	return next.Sequence, true
 }
 
 func (be *EmojiEngine) RemoveLastKey() {
@@ -113,4 +474,5 @@ func (be *EmojiEngine) RemoveLastKey() {
 		return
 	}
 	be.keys = be.keys[:len(be.keys)-1]
+	be.queried = false
 }
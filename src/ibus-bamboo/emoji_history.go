@@ -0,0 +1,182 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	emojiHistoryMaxEntries = 200
+	emojiHistoryHalfLife   = 14 * 24 * time.Hour
+)
+
+type emojiUsage struct {
+	Sequence string
+	Count    int
+	LastUsed int64 // unix time
+}
+
+// EmojiHistory keeps track of the committed emoji. An emoji's score is its usage count
+// weighted by how long ago it was last used, so both frequent and recent emoji get boosted.
+type EmojiHistory struct {
+	path    string
+	entries map[string]*emojiUsage
+}
+
+func NewEmojiHistory(path string) *EmojiHistory {
+	return &EmojiHistory{
+		path:    path,
+		entries: map[string]*emojiUsage{},
+	}
+}
+
+func loadEmojiHistory(path string) *EmojiHistory {
+	var h = NewEmojiHistory(path)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	var usages []*emojiUsage
+	if json.Unmarshal(data, &usages) == nil {
+		for _, usage := range usages {
+			if usage.Sequence != "" && usage.Count > 0 {
+				h.entries[usage.Sequence] = usage
+			}
+		}
+		h.truncate(time.Now())
+	}
+	return h
+}
+
+func (h *EmojiHistory) Save() error {
+	if h.path == "" {
+		return nil
+	}
+	var usages []*emojiUsage
+	for _, usage := range h.entries {
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].LastUsed > usages[j].LastUsed
+	})
+	data, err := json.MarshalIndent(usages, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.path, data, 0644)
+}
+
+func (h *EmojiHistory) Len() int {
+	return len(h.entries)
+}
+
+func (h *EmojiHistory) Add(seq string) {
+	h.add(seq, time.Now())
+}
+
+func (h *EmojiHistory) add(seq string, now time.Time) {
+	if seq == "" {
+		return
+	}
+	var usage = h.entries[seq]
+	if usage == nil {
+		usage = &emojiUsage{Sequence: seq}
+		h.entries[seq] = usage
+	}
+	usage.Count++
+	usage.LastUsed = now.Unix()
+	h.truncate(now)
+}
+
+// truncate drops the lowest scored entries so that the history file stays small
+func (h *EmojiHistory) truncate(now time.Time) {
+	if len(h.entries) <= emojiHistoryMaxEntries {
+		return
+	}
+	var usages []*emojiUsage
+	for _, usage := range h.entries {
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].score(now) > usages[j].score(now)
+	})
+	for _, usage := range usages[emojiHistoryMaxEntries:] {
+		delete(h.entries, usage.Sequence)
+	}
+}
+
+func (u *emojiUsage) score(now time.Time) float64 {
+	var age = now.Sub(time.Unix(u.LastUsed, 0))
+	if age < 0 {
+		age = 0
+	}
+	return float64(u.Count) * math.Pow(0.5, float64(age)/float64(emojiHistoryHalfLife))
+}
+
+// scores returns the score of every used emoji, keyed by its sequence without skin tones and
+// variation selectors, so that using 👋🏽 also boosts 👋 in the candidate list.
+func (h *EmojiHistory) scores(now time.Time) map[string]float64 {
+	var scores = map[string]float64{}
+	for seq, usage := range h.entries {
+		scores[stripSkinTones(stripVariationSelectors(seq))] += usage.score(now)
+	}
+	return scores
+}
+
+// Recent returns at most n emoji, the most recently used first.
+func (h *EmojiHistory) Recent(n int) []string {
+	var usages []*emojiUsage
+	for _, usage := range h.entries {
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].LastUsed != usages[j].LastUsed {
+			return usages[i].LastUsed > usages[j].LastUsed
+		}
+		return usages[i].Count > usages[j].Count
+	})
+	var recent []string
+	for i := 0; i < len(usages) && i < n; i++ {
+		recent = append(recent, usages[i].Sequence)
+	}
+	return recent
+}
+
+// Rank moves the used emoji to the front, the higher scored first. The order of
+// the other candidates is kept.
+func (h *EmojiHistory) Rank(codePoints []string) []string {
+	return h.rank(codePoints, time.Now())
+}
+
+func (h *EmojiHistory) rank(codePoints []string, now time.Time) []string {
+	if len(h.entries) == 0 {
+		return codePoints
+	}
+	var scores = h.scores(now)
+	sort.SliceStable(codePoints, func(i, j int) bool {
+		return scores[stripSkinTones(stripVariationSelectors(codePoints[i]))] > scores[stripSkinTones(stripVariationSelectors(codePoints[j]))]
+	})
+	return codePoints
+}
@@ -0,0 +1,109 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEmojiHistoryRank(t *testing.T) {
+	var now = time.Now()
+	var h = NewEmojiHistory("")
+	h.add("😂", now.Add(-60*24*time.Hour))
+	h.add("😂", now.Add(-60*24*time.Hour))
+	h.add("😂", now.Add(-60*24*time.Hour))
+	h.add("😍", now.Add(-time.Hour))
+	h.add("👋🏽", now)
+	var ranked = h.rank([]string{"😀", "😂", "😍", "👋", "😃"}, now)
+	var expected = []string{"👋", "😍", "😂", "😀", "😃"}
+	if fmt.Sprint(ranked) != fmt.Sprint(expected) {
+		t.Errorf("Rank emoji, expected %v, got %v", expected, ranked)
+	}
+	h.add("😂", now)
+	ranked = h.rank([]string{"😀", "😂", "😍", "👋"}, now)
+	if ranked[0] != "😂" {
+		t.Errorf("Rank emoji, expected 😂 first, got %v", ranked)
+	}
+	if recent := h.Recent(2); fmt.Sprint(recent) != fmt.Sprint([]string{"😂", "👋🏽"}) {
+		t.Errorf("Recent emoji, expected [😂 👋🏽], got %v", recent)
+	}
+}
+
+func TestEmojiHistoryBounded(t *testing.T) {
+	var now = time.Now()
+	var h = NewEmojiHistory("")
+	h.add("😂", now)
+	h.add("😂", now)
+	for i := 0; i < emojiHistoryMaxEntries+50; i++ {
+		h.add(string(rune(0x1F600+i)), now.Add(-time.Duration(i)*time.Hour))
+	}
+	if h.Len() != emojiHistoryMaxEntries {
+		t.Errorf("Emoji history size, expected %d, got %d", emojiHistoryMaxEntries, h.Len())
+	}
+	if h.entries["😂"] == nil {
+		t.Errorf("The most used emoji was dropped from the history")
+	}
+}
+
+func TestEmojiHistorySaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ibus-bamboo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "emoji.history.json")
+	var h = NewEmojiHistory(path)
+	h.Add("😍")
+	h.Add("😍")
+	h.Add("🎉")
+	if err = h.Save(); err != nil {
+		t.Fatal(err)
+	}
+	var loaded = loadEmojiHistory(path)
+	if loaded.Len() != 2 || loaded.entries["😍"].Count != 2 {
+		t.Errorf("Load emoji history, expected 2 entries, got %d", loaded.Len())
+	}
+	if h = loadEmojiHistory(filepath.Join(dir, "missing.json")); h.Len() != 0 {
+		t.Errorf("Load a missing emoji history, expected an empty history")
+	}
+}
+
+func TestEmojiRecentPage(t *testing.T) {
+	loadTestEmojis()
+	var be = NewEmojiEngine()
+	be.History = NewEmojiHistory("")
+	be.History.Add("🎉")
+	be.ProcessKey(':')
+	var cps = be.Query()
+	if len(cps) < 2 || cps[0] != "🎉" {
+		t.Errorf("Query an empty emoji list, expected the recent emoji first, got %v", cps)
+	}
+	be.Reset()
+	for _, key := range "party" {
+		be.ProcessKey(key)
+	}
+	if cps = be.Query(); len(cps) == 0 || cps[0] != "🎉" {
+		t.Errorf("Query `party`, expected 🎉 first, got %v", cps)
+	}
+}
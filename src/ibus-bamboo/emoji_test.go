@@ -22,8 +22,16 @@ import (
 	"testing"
 )
 
+func loadTestEmojis() {
+	var annotationFiles []string
+	for _, f := range DictEmojiAnnotations {
+		annotationFiles = append(annotationFiles, "../../"+f)
+	}
+	emojiTable, _ = loadEmojiTable("../../"+DictEmojiTest, "../../"+DictEmoticons, annotationFiles...)
+}
+
 func TestEmojiFindResult(t *testing.T) {
-	emojiTrie, _ = loadEmojiOne("../../" + DictEmojiOne)
+	loadTestEmojis()
 	var be = NewEmojiEngine()
 	if be.MatchString(":'") != true {
 		t.Errorf("Finding result for emoji :', expected true, got %v", be.MatchString(":'"))
@@ -39,8 +47,24 @@ func TestEmojiFindResult(t *testing.T) {
 	}
 }
 
+func TestEmojiFuzzyFindCache(t *testing.T) {
+	loadTestEmojis()
+	var be = NewEmojiEngine()
+	if !be.MatchString("cat smiling") || be.fuzzyString != "cat smiling" || len(be.fuzzyMatches) == 0 {
+		t.Fatalf("Match cat smiling, got the cached matches of [%s] %v", be.fuzzyString, be.fuzzyMatches)
+	}
+	// the table is not searched again for the same keys
+	be.fuzzyMatches = []string{"x"}
+	if candidates := be.Filter("cat smiling"); !inStringList(candidates, "x") {
+		t.Errorf("Filter cat smiling after matching it, expected the cached matches, got %v", candidates)
+	}
+	if be.MatchString("grining") && inStringList(be.fuzzyMatches, "x") {
+		t.Errorf("Match another string, expected the cache to be refreshed")
+	}
+}
+
 func TestFilterEmoji(t *testing.T) {
-	emojiTrie, _ = loadEmojiOne("../../" + DictEmojiOne)
+	loadTestEmojis()
 	var be = NewEmojiEngine()
 	var grinnings = be.Filter(":')")
 	if !inStringList(grinnings, "😂") {
@@ -55,3 +79,96 @@ func TestFilterEmoji(t *testing.T) {
 		t.Errorf("Filtering emojo `grin`, expected %v got %v", true, inStringList(grinnings3, "😀"))
 	}
 }
+
+func TestFilterVietnameseEmoji(t *testing.T) {
+	loadTestEmojis()
+	var be = NewEmojiEngine()
+	if hearts := be.Filter("tim"); !inStringList(hearts, "❤️") {
+		t.Errorf("Filtering emoji `tim`, expected %v got %v", true, inStringList(hearts, "❤️"))
+	}
+	if smiles := be.Filter("cuoi"); !inStringList(smiles, "😀") {
+		t.Errorf("Filtering emoji `cuoi`, expected %v got %v", true, inStringList(smiles, "😀"))
+	}
+	if smiles := be.Filter("cười"); !inStringList(smiles, "😀") {
+		t.Errorf("Filtering emoji `cười`, expected %v got %v", true, inStringList(smiles, "😀"))
+	}
+}
+
+func TestEmojiVersion(t *testing.T) {
+	loadTestEmojis()
+	if emojiTable.Version != "15.1" {
+		t.Errorf("Emoji data version, expected 15.1, got %s", emojiTable.Version)
+	}
+	var emoji = emojiTable.Lookup("❤")
+	if emoji == nil || emoji.Sequence != "❤️" || emoji.Version != "0.6" || emoji.Name != "red heart" {
+		t.Errorf("Lookup emoji ❤, got %+v", emoji)
+	}
+	// emoji that came after EmojiOne was frozen
+	if melting := emojiTable.Lookup("\U0001FAE0"); melting == nil || melting.Version != "14.0" {
+		t.Errorf("Lookup melting face, got %+v", melting)
+	}
+	if melting := NewEmojiEngine().Filter("melting"); !inStringList(melting, "\U0001FAE0") {
+		t.Errorf("Filtering emoji `melting`, expected %v got %v", true, inStringList(melting, "\U0001FAE0"))
+	}
+}
+
+func TestEmojiVariants(t *testing.T) {
+	loadTestEmojis()
+	var be = NewEmojiEngine()
+	var waves = be.Filter("waving hand")
+	if len(waves) != 1 || waves[0] != "👋" {
+		t.Errorf("Filtering emoji `waving hand`, expected [👋] got %v", waves)
+	}
+	var variants = emojiTable.Variants("👋")
+	if len(variants) != 6 || variants[3].Sequence != "👋🏽" || variants[3].Tone != 3 {
+		t.Errorf("Variants of 👋, got %d variants", len(variants))
+	}
+	var runners = emojiTable.Variants("🏃‍♀️")
+	if len(runners) == 0 || runners[0].Sequence != "🏃" {
+		t.Errorf("Variants of 🏃‍♀️, expected the group of 🏃")
+	}
+	if s := emojiTable.WithSkinTone("🏃‍♀️", 5); s != "🏃🏿‍♀️" {
+		t.Errorf("WithSkinTone 🏃‍♀️, expected 🏃🏿‍♀️ got %s", s)
+	}
+	if s := emojiTable.WithSkinTone("😀", 2); s != "😀" {
+		t.Errorf("WithSkinTone 😀, expected 😀 got %s", s)
+	}
+}
+
+func TestCycleEmojiVariant(t *testing.T) {
+	loadTestEmojis()
+	var be = NewEmojiEngine()
+	be.SkinTone = 2
+	for _, key := range "waving hand" {
+		be.ProcessKey(key)
+	}
+	if cps := be.Query(); len(cps) != 1 || cps[0] != "👋🏼" {
+		t.Errorf("Query `waving hand` with the preferred skin tone, expected [👋🏼] got %v", cps)
+	}
+	if variant, _ := be.CycleVariant(0); variant != "👋🏽" {
+		t.Errorf("Cycle the variant of 👋🏼, expected 👋🏽 got %s", variant)
+	}
+	be.CycleVariant(0)
+	be.CycleVariant(0)
+	if variant, _ := be.CycleVariant(0); variant != "👋" {
+		t.Errorf("Cycle the variant of 👋🏿, expected 👋 got %s", variant)
+	}
+	if cps := be.Query(); cps[0] != "👋" {
+		t.Errorf("Query after cycling, expected 👋 got %s", cps[0])
+	}
+}
+
+// BenchmarkEmojiQuery measures the cost of a keystroke in the emoji picker
+func BenchmarkEmojiQuery(b *testing.B) {
+	loadTestEmojis()
+	var be = NewEmojiEngine()
+	var query = "smiling face"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		be.Reset()
+		for _, key := range query {
+			be.ProcessKey(key)
+			be.Query()
+		}
+	}
+}
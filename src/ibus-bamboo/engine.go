@@ -45,15 +45,28 @@ type IBusBambooEngine struct {
 	isInputModeLTOpened    bool
 	isEmojiLTOpened        bool
 	emojiLookupTable       *ibus.LookupTable
+	isUnicodeLTOpened      bool
+	unicodeLookupTable     *ibus.LookupTable
+	predictionLookupTable  *ibus.LookupTable
+	predictions            []string
+	hint                   string // the typing hint of the predictions
+	hintKeys               keystrokesCache
+	spellDiagnosis         string
+	lastSyllable           string
+	personalDict           *PersonalDictionary
 	inputModeLookupTable   *ibus.LookupTable
 	capabilities           uint32
 	keyPressDelay          int
 	nFakeBackSpace         int
 	isFirstTimeSendingBS   bool
 	emoji                  *EmojiEngine
+	unicode                *UnicodeEngine
 	isSurroundingTextReady bool
 	lastKeyWithShift       bool
 	lastCommitText         int64
+	isNormalizationPending bool
+	normalizationRequested int64
+	codePoints             []int // the code points of each rune before the cursor in the app
 }
 
 /**
@@ -72,6 +85,8 @@ Return:
 This function gets called whenever a key is pressed.
 */
 func (e *IBusBambooEngine) ProcessKeyEvent(keyVal uint32, keyCode uint32, state uint32) (bool, *dbus.Error) {
+	// the text may have changed since the normalization was requested
+	e.isNormalizationPending = false
 	if e.checkInputMode(usIM) {
 		if e.isInputModeLTOpened || keyVal == IBusOpenLookupTable {
 			// return false, nil
@@ -93,7 +108,15 @@ func (e *IBusBambooEngine) ProcessKeyEvent(keyVal uint32, keyCode uint32, state
 		e.openLookupTable()
 		return true, nil
 	}
-	if e.config.IBflags&IBemojiDisabled == 0 && keyVal == IBusColon && !e.isEmojiLTOpened {
+	if e.config.IBflags&IBunicodePickerEnabled != 0 && keyVal == IBusColon && state&IBusControlMask != 0 &&
+		!e.isUnicodeLTOpened && !e.isEmojiLTOpened {
+		e.resetBuffer()
+		e.isUnicodeLTOpened = true
+		e.lastKeyWithShift = true
+		e.openUnicodeList()
+		return true, nil
+	}
+	if e.config.IBflags&IBemojiDisabled == 0 && keyVal == IBusColon && !e.isEmojiLTOpened && !e.isUnicodeLTOpened {
 		e.resetBuffer()
 		e.isEmojiLTOpened = true
 		e.lastKeyWithShift = true
@@ -106,6 +129,9 @@ func (e *IBusBambooEngine) ProcessKeyEvent(keyVal uint32, keyCode uint32, state
 	if e.isEmojiLTOpened {
 		return e.emojiProcessKeyEvent(keyVal, keyCode, state)
 	}
+	if e.isUnicodeLTOpened {
+		return e.unicodeProcessKeyEvent(keyVal, keyCode, state)
+	}
 	if e.englishMode {
 		e.updateLastKeyWithShift(keyVal, state)
 		return false, nil
@@ -118,6 +144,7 @@ func (e *IBusBambooEngine) ProcessKeyEvent(keyVal uint32, keyCode uint32, state
 
 func (e *IBusBambooEngine) FocusIn() *dbus.Error {
 	log.Print("FocusIn.")
+	e.isNormalizationPending = false
 	var latestWm string
 	if isGnome && isGnomeOverviewVisible() {
 		latestWm = ""
@@ -125,6 +152,10 @@ func (e *IBusBambooEngine) FocusIn() *dbus.Error {
 		latestWm = e.getLatestWmClass()
 	}
 	e.checkWmClass(latestWm)
+	e.resumeComposition()
+	if e.personalDict != nil && e.personalDict.Reload() && dictionary.Len() > 0 {
+		e.loadDictionaries()
+	}
 	e.RegisterProperties(e.propList)
 	e.RequireSurroundingText()
 	fmt.Printf("WM_CLASS=(%s)\n", e.getWmClass())
@@ -133,11 +164,15 @@ func (e *IBusBambooEngine) FocusIn() *dbus.Error {
 
 func (e *IBusBambooEngine) FocusOut() *dbus.Error {
 	log.Print("FocusOut.")
+	e.isNormalizationPending = false
+	e.hidePredictions()
+	e.keepComposition()
 	return nil
 }
 
 func (e *IBusBambooEngine) Reset() *dbus.Error {
 	fmt.Print("Reset.\n")
+	e.hidePredictions()
 	if e.checkInputMode(preeditIM) {
 		e.commitPreedit(e.getPreeditString())
 	}
@@ -157,6 +192,12 @@ func (e *IBusBambooEngine) Disable() *dbus.Error {
 
 //@method(in_signature="vuu")
 func (e *IBusBambooEngine) SetSurroundingText(text dbus.Variant, cursorPos uint32, anchorPos uint32) *dbus.Error {
+	if e.isNormalizationDue() {
+		e.Lock()
+		defer e.Unlock()
+		e.normalizeSurroundingText(text, cursorPos, anchorPos)
+		return nil
+	}
 	if !e.isSurroundingTextReady {
 		//fmt.Println("Surrounding Text is not ready yet.")
 		return nil
@@ -175,7 +216,9 @@ func (e *IBusBambooEngine) SetSurroundingText(text dbus.Variant, cursorPos uint3
 		if len(s) < int(cursorPos) {
 			return nil
 		}
-		var cs = s[:cursorPos]
+		// decomposed letters would be processed before their base letters in the reverse order
+		var cs []rune
+		cs, e.codePoints = composeCodePoints(s[:cursorPos])
 		fmt.Println("Surrounding Text: ", string(cs))
 		e.preeditor.Reset()
 		for i := len(cs) - 1; i >= 0; i-- {
@@ -193,6 +236,9 @@ func (e *IBusBambooEngine) PageUp() *dbus.Error {
 	if e.isEmojiLTOpened && e.emojiLookupTable.PageUp() {
 		e.updateEmojiLookupTable()
 	}
+	if e.isUnicodeLTOpened && e.unicodeLookupTable.PageUp() {
+		e.updateUnicodeLookupTable()
+	}
 	if e.isInputModeLTOpened && e.inputModeLookupTable.PageUp() {
 		e.updateInputModeLT()
 	}
@@ -203,6 +249,9 @@ func (e *IBusBambooEngine) PageDown() *dbus.Error {
 	if e.isEmojiLTOpened && e.emojiLookupTable.PageDown() {
 		e.updateEmojiLookupTable()
 	}
+	if e.isUnicodeLTOpened && e.unicodeLookupTable.PageDown() {
+		e.updateUnicodeLookupTable()
+	}
 	if e.isInputModeLTOpened && e.inputModeLookupTable.PageDown() {
 		e.updateInputModeLT()
 	}
@@ -213,6 +262,9 @@ func (e *IBusBambooEngine) CursorUp() *dbus.Error {
 	if e.isEmojiLTOpened && e.emojiLookupTable.CursorUp() {
 		e.updateEmojiLookupTable()
 	}
+	if e.isUnicodeLTOpened && e.unicodeLookupTable.CursorUp() {
+		e.updateUnicodeLookupTable()
+	}
 	if e.isInputModeLTOpened && e.inputModeLookupTable.CursorUp() {
 		e.updateInputModeLT()
 	}
@@ -223,6 +275,9 @@ func (e *IBusBambooEngine) CursorDown() *dbus.Error {
 	if e.isEmojiLTOpened && e.emojiLookupTable.CursorDown() {
 		e.updateEmojiLookupTable()
 	}
+	if e.isUnicodeLTOpened && e.unicodeLookupTable.CursorDown() {
+		e.updateUnicodeLookupTable()
+	}
 	if e.isInputModeLTOpened && e.inputModeLookupTable.CursorDown() {
 		e.updateInputModeLT()
 	}
@@ -233,8 +288,12 @@ func (e *IBusBambooEngine) CandidateClicked(index uint32, button uint32, state u
 	if e.isEmojiLTOpened && e.updateCursorPosInEmojiTable(index) {
 		e.commitEmojiCandidate()
 		e.closeEmojiCandidates()
-	}
-	if e.isInputModeLTOpened && e.inputModeLookupTable.SetCursorPos(index) {
+	} else if e.predictionLookupTable != nil && e.predictionLookupTable.SetCursorPos(index) {
+		e.commitPrediction()
+	} else if e.isUnicodeLTOpened && e.updateCursorPosInUnicodeTable(index) {
+		e.commitUnicodeCandidate()
+		e.closeUnicodeCandidates()
+	} else if e.isInputModeLTOpened && e.inputModeLookupTable.SetCursorPos(index) {
 		e.commitInputModeCandidate()
 		e.closeInputModeCandidates()
 	}
@@ -272,6 +331,14 @@ func (e *IBusBambooEngine) PropertyActivate(propName string, propState uint32) *
 		OpenMactabFile(e.engineName)
 		return nil
 	}
+	if propName == PropKeyPersonalDict {
+		OpenPersonalDictFile(e.engineName)
+		return nil
+	}
+	if propName == PropKeyNormalizeToneStyle {
+		e.normalizeToneStyle()
+		return nil
+	}
 
 	turnSpellChecking := func(on bool) {
 		if on {
@@ -289,12 +356,64 @@ func (e *IBusBambooEngine) PropertyActivate(propName string, propState uint32) *
 	if propName == PropKeyEmojiEnabled {
 		if propState == ibus.PROP_STATE_CHECKED {
 			e.config.IBflags &= ^IBemojiDisabled
-			emojiTrie, _ = loadEmojiOne(DictEmojiOne)
+			loadEmojis()
 		} else {
 			e.config.IBflags |= IBemojiDisabled
 		}
 	}
 
+	if propName == PropKeyEnglishDict {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.IBflags |= IBenglishDictEnabled
+			e.loadEnglishDictionary()
+		} else {
+			e.config.IBflags &= ^IBenglishDictEnabled
+		}
+	}
+
+	if propName == PropKeySpellDiagnosis {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.IBflags |= IBspellDiagnosisEnabled
+		} else {
+			e.config.IBflags &= ^IBspellDiagnosisEnabled
+			e.spellDiagnosis = ""
+			e.updateAuxiliaryText()
+		}
+	}
+
+	if propName == PropKeyAutoComplete {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.IBflags |= IBautoCompleteEnabled
+			if dictionary.Len() == 0 {
+				e.loadDictionaries()
+			}
+			if predictor.IsEmpty() {
+				loadPredictions()
+			}
+		} else {
+			e.config.IBflags &= ^IBautoCompleteEnabled
+			e.hidePredictions()
+		}
+	}
+
+	if propName == PropKeyTypingHint {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.IBflags |= IBtypingHintEnabled
+		} else {
+			e.config.IBflags &= ^IBtypingHintEnabled
+			e.hint = ""
+			e.updateAuxiliaryText()
+		}
+	}
+
+	if propName == PropKeyUnicodePicker {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.IBflags |= IBunicodePickerEnabled
+		} else {
+			e.config.IBflags &= ^IBunicodePickerEnabled
+		}
+	}
+
 	if propName == PropKeyStdToneStyle {
 		if propState == ibus.PROP_STATE_CHECKED {
 			e.config.Flags |= bamboo.EstdToneStyle
@@ -302,6 +421,34 @@ func (e *IBusBambooEngine) PropertyActivate(propName string, propState uint32) *
 			e.config.Flags &= ^bamboo.EstdToneStyle
 		}
 	}
+	if propName == PropKeyResumeComposition {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.IBflags |= IBresumeComposition
+		} else {
+			e.config.IBflags &= ^IBresumeComposition
+		}
+	}
+	if propName == PropKeyQuickTelex {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.Flags |= bamboo.EquickTelex
+		} else {
+			e.config.Flags &= ^bamboo.EquickTelex
+		}
+	}
+	if propName == PropKeyQuickStartConsonant {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.Flags |= bamboo.EquickStartConsonant
+		} else {
+			e.config.Flags &= ^bamboo.EquickStartConsonant
+		}
+	}
+	if propName == PropKeyQuickEndConsonant {
+		if propState == ibus.PROP_STATE_CHECKED {
+			e.config.Flags |= bamboo.EquickEndConsonant
+		} else {
+			e.config.Flags &= ^bamboo.EquickEndConsonant
+		}
+	}
 	if propName == PropKeyFreeToneMarking {
 		if propState == ibus.PROP_STATE_CHECKED {
 			e.config.Flags |= bamboo.EfreeToneMarking
@@ -328,7 +475,9 @@ func (e *IBusBambooEngine) PropertyActivate(propName string, propState uint32) *
 		if propState == ibus.PROP_STATE_CHECKED {
 			e.config.IBflags |= IBspellCheckWithDicts
 			turnSpellChecking(true)
-			dictionary, _ = loadDictionary(DictVietnameseCm)
+			if dictionary.Len() == 0 {
+				e.loadDictionaries()
+			}
 		} else {
 			e.config.IBflags &= ^IBspellCheckWithDicts
 		}
@@ -400,11 +549,15 @@ func (e *IBusBambooEngine) PropertyActivate(propName string, propState uint32) *
 	if foundIm && propState == ibus.PROP_STATE_CHECKED {
 		e.config.DefaultInputMode, _ = strconv.Atoi(im)
 	}
+	var bias, foundBias = getValueFromPropKey(propName, "EnglishBias")
+	if foundBias && propState == ibus.PROP_STATE_CHECKED {
+		e.config.EnglishBias, _ = strconv.Atoi(bias)
+	}
 	var charset, foundCs = getValueFromPropKey(propName, "OutputCharset")
 	if foundCs && isValidCharset(charset) && propState == ibus.PROP_STATE_CHECKED {
 		e.config.OutputCharset = charset
 	}
-	if _, found := e.config.InputMethodDefinitions[propName]; found && propState == ibus.PROP_STATE_CHECKED {
+	if _, found := e.config.getInputMethodDefinitions()[propName]; found && propState == ibus.PROP_STATE_CHECKED {
 		e.config.InputMethod = propName
 	}
 	if propName != "-" {
@@ -412,8 +565,7 @@ func (e *IBusBambooEngine) PropertyActivate(propName string, propState uint32) *
 	}
 	e.propList = GetPropListByConfig(e.config)
 
-	var inputMethod = bamboo.ParseInputMethod(e.config.InputMethodDefinitions, e.config.InputMethod)
-	e.preeditor = bamboo.NewEngine(inputMethod, e.config.Flags)
+	e.preeditor = newPreeditor(e.config, e.engineName)
 	e.RegisterProperties(e.propList)
 	return nil
 }
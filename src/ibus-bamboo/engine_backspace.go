@@ -45,6 +45,7 @@ func (e *IBusBambooEngine) bsProcessKeyEvent(keyVal uint32, keyCode uint32, stat
 	if isMovementKey(keyVal) {
 		e.preeditor.Reset()
 		e.resetFakeBackspace()
+		e.codePoints = nil
 		e.isSurroundingTextReady = true
 		return false, nil
 	}
@@ -319,6 +320,7 @@ func (e *IBusBambooEngine) getOffsetRunes(newText, oldText string) ([]rune, int)
 }
 
 func (e *IBusBambooEngine) SendBackSpace(n int) {
+	n = e.takeCodePoints(n)
 	// Gtk/Qt apps have a serious sync issue with fake backspaces
 	// and normal string committing, so we'll not commit right now
 	// but delay until all the sent backspaces got processed.
@@ -385,6 +387,7 @@ func (e *IBusBambooEngine) bsCommitText(rs []rune) {
 	if len(rs) == 0 {
 		return
 	}
+	e.addCodePoints(rs)
 	if e.checkInputMode(forwardAsCommitIM) {
 		log.Println("Forward as commit", string(rs))
 		for _, chr := range rs {
@@ -400,3 +403,53 @@ func (e *IBusBambooEngine) bsCommitText(rs []rune) {
 	}
 	e.commitText(string(rs))
 }
+
+// composeCodePoints composes the decomposed letters of the text before the cursor and counts the code
+// points of each composed rune, e.g. "Vie\u0302" gives "Viê" and [1 1 2]
+func composeCodePoints(text []rune) ([]rune, []int) {
+	var composed []rune
+	var codePoints []int
+	for _, chr := range text {
+		if n := len(composed); n > 0 && bamboo.IsCombiningChar(chr) {
+			if cs := []rune(bamboo.ComposeNFC(string([]rune{composed[n-1], chr}))); len(cs) == 1 {
+				composed[n-1] = cs[0]
+				codePoints[n-1]++
+				continue
+			}
+		}
+		composed = append(composed, chr)
+		codePoints = append(codePoints, 1)
+	}
+	return composed, codePoints
+}
+
+// addCodePoints counts the code points of the runes committed to the app, a letter of the decomposed
+// output charset takes several
+func (e *IBusBambooEngine) addCodePoints(rs []rune) {
+	for _, chr := range rs {
+		var n = 1
+		if !e.checkInputMode(forwardAsCommitIM) {
+			n = utf8.RuneCountInString(e.encodeText(string(chr)))
+		}
+		e.codePoints = append(e.codePoints, n)
+	}
+	// the runes before the text of the preeditor are never deleted
+	if kept := utf8.RuneCountInString(e.preeditor.GetProcessedString(bamboo.VietnameseMode | bamboo.FullText)); len(e.codePoints) > kept {
+		e.codePoints = e.codePoints[len(e.codePoints)-kept:]
+	}
+}
+
+// takeCodePoints turns a number of runes to delete before the cursor into the number of code points
+// they take in the app, so a backspace does not leave the base letter of a decomposed one behind
+func (e *IBusBambooEngine) takeCodePoints(n int) int {
+	var count = 0
+	for ; n > 0; n-- {
+		if len(e.codePoints) == 0 {
+			count++
+			continue
+		}
+		count += e.codePoints[len(e.codePoints)-1]
+		e.codePoints = e.codePoints[:len(e.codePoints)-1]
+	}
+	return count
+}
@@ -0,0 +1,85 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+	"github.com/godbus/dbus"
+)
+
+func TestSurroundingTextDecomposed(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var e = newTestEngine(r, im, IBstdFlags&^IBmouseCapturing)
+	e.config.DefaultInputMode = surroundingTextIM
+	e.isSurroundingTextReady = true
+	// "tiếng Việ" with decomposed letters
+	var text = dbus.MakeVariant([]interface{}{"IBusText", map[string]dbus.Variant{}, "tie\u0302\u0301ng Vie\u0323\u0302", dbus.MakeVariant("")})
+	e.SetSurroundingText(text, 13, 13)
+	if s := e.preeditor.GetProcessedString(bamboo.EnglishMode | bamboo.FullText); s != "tiếng Việ" {
+		t.Errorf("Surrounding text, got %+q expected [tiếng Việ]", s)
+	}
+	e.preeditor.ProcessKey('t', bamboo.VietnameseMode)
+	if s := e.preeditor.GetProcessedString(bamboo.VietnameseMode); s != "Việt" {
+		t.Errorf("Surrounding text then process [t], got %+q expected [Việt]", s)
+	}
+}
+
+func TestBackSpaceDecomposed(t *testing.T) {
+	var tests = []struct {
+		surrounding string
+		charset     string
+		keys        string
+		deleted     [2]int64
+		expected    string
+	}{
+		{"Vie\u0302", "Unicode", "j", [2]int64{-2, 2}, "Việ"},
+		{"Vie\u0302", "Unicode NFD", "j", [2]int64{-2, 2}, "Vie\u0323\u0302"},
+		{"", "Unicode NFD", "vieej", [2]int64{-2, 2}, "vie\u0323\u0302"},
+		{"thu\u031B", "Unicode", "owf", [2]int64{-1, 1}, "thu\u031Bờ"},
+	}
+	for _, test := range tests {
+		var r, err = newSignalRecorder()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+		var e = newTestEngine(r, im, IBstdFlags&^IBmouseCapturing)
+		e.config.DefaultInputMode = surroundingTextIM
+		e.config.OutputCharset = test.charset
+		e.isSurroundingTextReady = true
+		r.text = []rune(test.surrounding)
+		var text = dbus.MakeVariant([]interface{}{"IBusText", map[string]dbus.Variant{}, test.surrounding, dbus.MakeVariant("")})
+		e.SetSurroundingText(text, uint32(len(r.text)), uint32(len(r.text)))
+		for _, key := range test.keys {
+			e.keyPressHandler(uint32(key), 0, 0)
+		}
+		r.sync()
+		if r.deleted != test.deleted || string(r.text) != test.expected {
+			t.Errorf("Type [%s] after %+q in %s, got %v %+q expected %v %+q", test.keys, test.surrounding, test.charset, r.deleted, string(r.text), test.deleted, test.expected)
+		}
+		r.conn.Close()
+	}
+}
@@ -23,12 +23,15 @@ import (
 	"github.com/BambooEngine/bamboo-core"
 	"github.com/BambooEngine/goibus/ibus"
 	"github.com/godbus/dbus"
+	"log"
 	"strconv"
 )
 
 const EmojiMaxPageSize = 9
 
 func (e *IBusBambooEngine) openEmojiList() {
+	e.hidePredictions()
+	e.emoji.SkinTone = e.config.EmojiSkinTone
 	e.emoji.ProcessKey(':')
 	e.UpdatePreeditText(ibus.NewText(":"), 1, true)
 	e.UpdateAuxiliaryText(ibus.NewText(":"), true)
@@ -71,6 +74,10 @@ func (e *IBusBambooEngine) emojiProcessKeyEvent(keyVal uint32, keyCode uint32, s
 		}
 		return false, nil
 	}
+	if keyVal == IBusTab && len(e.emojiLookupTable.Candidates) > 0 {
+		e.cycleEmojiVariant()
+		return true, nil
+	}
 	if keyVal == IBusLeft || keyVal == IBusUp {
 		e.CursorUp()
 		return true, nil
@@ -173,6 +180,38 @@ func (e *IBusBambooEngine) commitEmojiCandidate() {
 	var cps = e.emoji.Query()
 	if pos := e.emojiLookupTable.CursorPos; pos < uint32(len(cps)) {
 		e.CommitText(ibus.NewText(cps[pos]))
+		e.rememberEmojiSkinTone(cps[pos])
+		e.emoji.History.Add(cps[pos])
+		if err := e.emoji.History.Save(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (e *IBusBambooEngine) cycleEmojiVariant() {
+	var pos = e.emojiLookupTable.CursorPos
+	if variant, ok := e.emoji.CycleVariant(int(pos)); ok {
+		e.emojiLookupTable.Candidates[pos] = dbus.MakeVariant(*ibus.NewText(variant))
+		e.updateEmojiLookupTable()
+	}
+}
+
+// the skin tone of the last committed emoji becomes the preferred one
+func (e *IBusBambooEngine) rememberEmojiSkinTone(codePoint string) {
+	var emoji = emojiTable.Lookup(codePoint)
+	if emoji == nil || emoji.Tone < 0 || emoji.Tone == e.config.EmojiSkinTone {
+		return
+	}
+	var hasToneVariants = false
+	for _, variant := range emojiTable.Variants(codePoint) {
+		if variant.Tone > 0 {
+			hasToneVariants = true
+			break
+		}
+	}
+	if hasToneVariants {
+		e.config.EmojiSkinTone = emoji.Tone
+		saveConfig(e.config, e.engineName)
 	}
 }
 
@@ -0,0 +1,107 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"github.com/BambooEngine/bamboo-core"
+	"github.com/godbus/dbus"
+	"log"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const NormalizationTimeoutMs = 500
+
+// readClipboard and writeClipboard use the clipboard tools of the desktop session
+var readClipboard = func() (string, error) {
+	var cmd = exec.Command("xclip", "-selection", "clipboard", "-o")
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmd = exec.Command("wl-paste", "--no-newline")
+	}
+	var out, err = cmd.Output()
+	return string(out), err
+}
+
+var writeClipboard = func(text string) error {
+	var cmd = exec.Command("xclip", "-selection", "clipboard", "-i")
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmd = exec.Command("wl-copy")
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// normalizeToneStyle places the tones of the selected text in the tone style of the config. The
+// selection comes with the surrounding text, so the clipboard is normalized instead when the
+// client cannot send it.
+func (e *IBusBambooEngine) normalizeToneStyle() {
+	if e.capabilities&IBusCapSurroundingText != 0 {
+		e.isNormalizationPending = true
+		e.normalizationRequested = time.Now().UnixNano()
+		e.RequireSurroundingText()
+		return
+	}
+	e.normalizeClipboard()
+}
+
+// isNormalizationDue tells whether the surrounding text is the answer to a normalization request, a
+// request which is not answered in time is dropped, so the next text is not replaced by surprise
+func (e *IBusBambooEngine) isNormalizationDue() bool {
+	if e.isNormalizationPending && time.Now().UnixNano()-e.normalizationRequested > NormalizationTimeoutMs*1000*1000 {
+		e.isNormalizationPending = false
+	}
+	return e.isNormalizationPending
+}
+
+func (e *IBusBambooEngine) normalizeClipboard() {
+	var text, err = readClipboard()
+	if err != nil {
+		log.Println("Failed to read the clipboard:", err)
+		return
+	}
+	if err := writeClipboard(bamboo.Normalize(text, e.config.Flags&bamboo.EstdToneStyle != 0)); err != nil {
+		log.Println("Failed to write the clipboard:", err)
+	}
+}
+
+// normalizeSurroundingText replaces the text between the cursor and the anchor with its
+// normalized form, or normalizes the clipboard if nothing is selected
+func (e *IBusBambooEngine) normalizeSurroundingText(text dbus.Variant, cursorPos, anchorPos uint32) {
+	e.isNormalizationPending = false
+	var s = []rune(reflect.ValueOf(reflect.ValueOf(text.Value()).Index(2).Interface()).String())
+	var start, end = cursorPos, anchorPos
+	if start > end {
+		start, end = end, start
+	}
+	if start == end || int(end) > len(s) {
+		e.normalizeClipboard()
+		return
+	}
+	var selection = string(s[start:end])
+	var normalized = bamboo.Normalize(bamboo.ComposeNFC(selection), e.config.Flags&bamboo.EstdToneStyle != 0)
+	if normalized == selection {
+		return
+	}
+	e.DeleteSurroundingText(int32(start)-int32(cursorPos), end-start)
+	e.commitText(normalized)
+}
@@ -0,0 +1,88 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+	"github.com/godbus/dbus"
+)
+
+func TestNormalizeToneStyle(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var e = newTestEngine(r, im, IBstdFlags&^IBmouseCapturing)
+	var clipboard = "hoà bình"
+	defer func(read func() (string, error), write func(string) error) {
+		readClipboard, writeClipboard = read, write
+	}(readClipboard, writeClipboard)
+	readClipboard = func() (string, error) { return clipboard, nil }
+	writeClipboard = func(text string) error { clipboard = text; return nil }
+
+	// without surrounding text
+	e.PropertyActivate(PropKeyNormalizeToneStyle, 0)
+	if clipboard != "hòa bình" {
+		t.Errorf("Normalize the clipboard, got [%s] expected [hòa bình]", clipboard)
+	}
+
+	// the selected text is replaced, from the anchor to the cursor
+	e.SetCapabilities(IBusCapSurroundingText)
+	e.config.Flags &^= bamboo.EstdToneStyle
+	e.PropertyActivate(PropKeyNormalizeToneStyle, 0)
+	var text = dbus.MakeVariant([]interface{}{"IBusText", map[string]dbus.Variant{}, "anh thúy, chị hòa", dbus.MakeVariant("")})
+	e.SetSurroundingText(text, 17, 4)
+	r.sync()
+	if r.deleted != [2]int64{-13, 13} || r.committed.String() != "thuý, chị hoà" {
+		t.Errorf("Normalize the selection, got %v [%s] expected [-13 13] [thuý, chị hoà]", r.deleted, r.committed.String())
+	}
+	if e.isNormalizationPending {
+		t.Errorf("Normalize the selection, the normalization is still pending")
+	}
+
+	// the clipboard is used when nothing is selected
+	e.PropertyActivate(PropKeyNormalizeToneStyle, 0)
+	e.SetSurroundingText(text, 4, 4)
+	if clipboard != "hoà bình" {
+		t.Errorf("Normalize without a selection, got [%s] expected [hoà bình]", clipboard)
+	}
+
+	// a request is dropped by the next key, a focus change or when it is not answered in time
+	var cancels = map[string]func(){
+		"key":       func() { e.ProcessKeyEvent(IBusShiftL, 0, IBusReleaseMask) },
+		"focus out": func() { e.FocusOut() },
+		"focus in":  func() { e.FocusIn() },
+		"timeout":   func() { e.normalizationRequested -= 2 * NormalizationTimeoutMs * 1000 * 1000 },
+	}
+	for name, cancel := range cancels {
+		r.committed.Reset()
+		r.deleted = [2]int64{}
+		e.PropertyActivate(PropKeyNormalizeToneStyle, 0)
+		cancel()
+		e.SetSurroundingText(text, 17, 4)
+		r.sync()
+		if r.deleted != [2]int64{} || r.committed.String() != "" || e.isNormalizationPending {
+			t.Errorf("Cancel the normalization by %s, got %v [%s] expected nothing", name, r.deleted, r.committed.String())
+		}
+	}
+}
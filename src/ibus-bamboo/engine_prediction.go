@@ -0,0 +1,157 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/BambooEngine/bamboo-core"
+	"github.com/BambooEngine/goibus/ibus"
+)
+
+// updatePredictions shows the completions of the syllable being typed, or the syllables that
+// usually follow the last committed one. The suggestions of Shift+Tab are cleared on the next key
+// even if the auto-complete is off.
+func (e *IBusBambooEngine) updatePredictions() {
+	if e.config.IBflags&IBautoCompleteEnabled == 0 {
+		e.hidePredictions()
+		return
+	}
+	var predictions []string
+	if e.getRawKeyLen() > 0 {
+		var typed = e.getPreeditString()
+		if typed == e.getProcessedString(bamboo.VietnameseMode) {
+			var key = e.getProcessedString(bamboo.VietnameseMode | bamboo.ToneLess | bamboo.MarkLess | bamboo.LowerCase)
+			for _, word := range predictor.Complete(dictionary, key, typed, PredictionMaxCandidates) {
+				predictions = append(predictions, matchCase(word, typed))
+			}
+		}
+	} else if e.lastSyllable != "" {
+		predictions = predictor.Next(e.lastSyllable, PredictionMaxCandidates)
+	}
+	e.showPredictions(predictions)
+}
+
+// showSuggestions lists the valid syllables that are close to a misspelled word, the ones in the
+// dictionary first. Like the predictions, they are committed by Tab or by a click.
+func (e *IBusBambooEngine) showSuggestions() bool {
+	var checker, ok = e.preeditor.(bamboo.ISyllableChecker)
+	if !ok || e.getRawKeyLen() == 0 || !e.isMisspelled() {
+		return false
+	}
+	var typed = e.getPreeditString()
+	var suggestions = checker.Suggest(0)
+	if dictionary.Len() > 0 {
+		sort.SliceStable(suggestions, func(i, j int) bool {
+			return dictionary.Has(suggestions[i]) && !dictionary.Has(suggestions[j])
+		})
+	}
+	if len(suggestions) > PredictionMaxCandidates {
+		suggestions = suggestions[:PredictionMaxCandidates]
+	}
+	for i, word := range suggestions {
+		suggestions[i] = matchCase(word, typed)
+	}
+	e.showPredictions(suggestions)
+	return len(suggestions) > 0
+}
+
+func (e *IBusBambooEngine) isMisspelled() bool {
+	if e.config.IBflags&IBspellCheckWithDicts != 0 {
+		return !e.inDictionary(e.getProcessedString(bamboo.VietnameseMode | bamboo.LowerCase))
+	}
+	return !e.preeditor.IsValid(true)
+}
+
+func (e *IBusBambooEngine) showPredictions(predictions []string) {
+	if len(predictions) == 0 {
+		e.hidePredictions()
+		return
+	}
+	lt := ibus.NewLookupTable()
+	lt.Orientation = IBusOrientationHorizontal
+	for _, word := range predictions {
+		lt.AppendCandidate(word)
+	}
+	lt.PageSize = uint32(PredictionMaxCandidates)
+	e.predictions = predictions
+	e.predictionLookupTable = lt
+	e.UpdateLookupTable(lt, true)
+	if e.config.IBflags&IBtypingHintEnabled != 0 {
+		e.hint = e.hintKeys.typingHint(predictions, e.preeditor.GetInputMethod(), e.config.Flags)
+		e.updateAuxiliaryText()
+	}
+}
+
+// maxCachedKeystrokes bounds the words whose key strokes are kept for the typing hints
+const maxCachedKeystrokes = 1024
+
+// keystrokesCache keeps the key strokes of the predicted words, as the predictions change at every
+// key and bamboo.Keystrokes replays an engine for each style it tries
+type keystrokesCache struct {
+	im    string
+	flags uint
+	keys  map[string]string // "" if the word cannot be typed
+}
+
+func (c *keystrokesCache) keystrokes(word string, im bamboo.InputMethod, flags uint) string {
+	if c.keys == nil || c.im != im.Name || c.flags != flags || len(c.keys) >= maxCachedKeystrokes {
+		c.im, c.flags, c.keys = im.Name, flags, map[string]string{}
+	}
+	var keys, found = c.keys[word]
+	if !found {
+		keys, _ = bamboo.Keystrokes(word, im, flags)
+		c.keys[word] = keys
+	}
+	return keys
+}
+
+// typingHint tells how to type the candidates with the current input method, like a typing tutor
+func (c *keystrokesCache) typingHint(words []string, im bamboo.InputMethod, flags uint) string {
+	var hints []string
+	for _, word := range words {
+		if keys := c.keystrokes(word, im, flags); keys != "" && keys != word {
+			hints = append(hints, word+": "+keys)
+		}
+	}
+	return strings.Join(hints, "  ")
+}
+
+func (e *IBusBambooEngine) commitPrediction() {
+	if pos := e.predictionLookupTable.CursorPos; pos < uint32(len(e.predictions)) {
+		e.commitPreedit(e.predictions[pos])
+	}
+	e.lastSyllable = ""
+	e.hidePredictions()
+}
+
+func (e *IBusBambooEngine) hidePredictions() {
+	if e.predictionLookupTable == nil {
+		return
+	}
+	e.predictions = nil
+	e.predictionLookupTable = nil
+	e.HideLookupTable()
+	if e.config.IBflags&IBtypingHintEnabled != 0 {
+		e.hint = ""
+		e.updateAuxiliaryText()
+	}
+}
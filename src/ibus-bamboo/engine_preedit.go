@@ -22,6 +22,7 @@ package main
 import (
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BambooEngine/bamboo-core"
@@ -34,6 +35,18 @@ func (e *IBusBambooEngine) preeditProcessKeyEvent(keyVal uint32, keyCode uint32,
 	var keyRune = rune(keyVal)
 	var oldText = e.getPreeditString()
 	defer e.updateLastKeyWithShift(keyVal, state)
+	e.lastSyllable = ""
+	if (keyVal == IBusLeftTab || keyVal == IBusTab && state&IBusShiftMask != 0) && e.showSuggestions() {
+		return true, nil
+	}
+	defer e.updatePredictions()
+
+	if keyVal == IBusTab && state&IBusShiftMask == 0 && len(e.predictions) > 0 {
+		if ok, _ := e.getMacroText(); !ok {
+			e.commitPrediction()
+			return true, nil
+		}
+	}
 
 	// workaround for chrome's address bar and Google SpreadSheets
 	if !e.isValidState(state) || !e.canProcessKey(keyVal) ||
@@ -56,36 +69,36 @@ func (e *IBusBambooEngine) preeditProcessKeyEvent(keyVal uint32, keyCode uint32,
 		}
 	}
 	if keyVal == IBusTab {
-		if ok, macText := e.getMacroText(); ok {
-			e.commitPreedit(macText)
-		} else {
-			e.commitPreedit(e.getComposedString(oldText))
-			return false, nil
-		}
-		return true, nil
+		// Tab expands a macro, otherwise it is passed on after the word
+		var word, expanded = e.wordComposer().CompleteWord()
+		e.commitPreedit(word)
+		return expanded, nil
 	}
 
 	if e.preeditor.CanProcessKey(keyRune) {
 		if state&IBusLockMask != 0 {
 			keyRune = e.toUpper(keyRune)
 		}
-		e.preeditor.ProcessKey(keyRune, e.getBambooInputMode())
-		if inKeyList(e.preeditor.GetInputMethod().AppendingKeys, keyRune) {
-			if fullSeq := e.preeditor.GetProcessedString(bamboo.VietnameseMode); len(fullSeq) > 0 && rune(fullSeq[len(fullSeq)-1]) == keyRune {
-				e.commitPreedit(fullSeq)
-			} else if newText := e.getPreeditString(); newText != "" && keyRune == rune(newText[len(newText)-1]) {
-				e.commitPreedit(oldText + string(keyRune))
-			} else {
-				e.updatePreedit(e.getPreeditString())
-			}
+		if text, ok := e.wordComposer().ProcessKey(keyRune); ok {
+			e.commitPreedit(text)
 		} else {
 			e.updatePreedit(e.getPreeditString())
 		}
 		return true, nil
 	} else if bamboo.IsWordBreakSymbol(keyRune) {
+		if keyVal == IBusSpace && state&IBusShiftMask != 0 && !e.lastKeyWithShift &&
+			e.config.IBflags&IBrestoreKeyStrokesEnabled == 0 && e.isRejectedByDictionary(oldText) {
+			// keep the word that the dictionary rejected, Shift+Space restores the key strokes instead if enabled
+			e.commitPreedit(oldText + string(keyRune))
+			e.overrideDictionary(oldText)
+			return true, nil
+		}
 		if keyVal == IBusSpace && state&IBusShiftMask != 0 &&
 			e.config.IBflags&IBrestoreKeyStrokesEnabled != 0 && !e.lastKeyWithShift {
-			// restore key strokes
+			// restore key strokes, the user overrides the dictionary as well
+			if e.isRejectedByDictionary(oldText) {
+				e.overrideDictionary(oldText)
+			}
 			var vnSeq = e.preeditor.GetProcessedString(bamboo.VietnameseMode)
 			if bamboo.HasAnyVietnameseRune(vnSeq) {
 				e.commitPreedit(e.preeditor.GetProcessedString(bamboo.EnglishMode))
@@ -94,28 +107,23 @@ func (e *IBusBambooEngine) preeditProcessKeyEvent(keyVal uint32, keyCode uint32,
 			}
 			return true, nil
 		}
-		if ok, macText := e.getMacroText(); ok {
-			e.commitPreedit(macText + string(keyRune))
-			return true, nil
+		var word, expanded = e.wordComposer().CompleteWord()
+		e.commitPreedit(word + string(keyRune))
+		if keyVal == IBusSpace && !expanded {
+			e.lastSyllable = word
 		}
-		e.commitPreedit(e.getComposedString(oldText) + string(keyRune))
 		return true, nil
 	}
 	e.commitPreedit(e.getPreeditString())
 	return false, nil
 }
 
-func (e *IBusBambooEngine) expandMacro(str string) string {
-	var macroText = e.macroTable.GetText(str)
-	if e.config.IBflags&IBautoCapitalizeMacro != 0 {
-		switch determineMacroCase(str) {
-		case VnCaseAllSmall:
-			return strings.ToLower(macroText)
-		case VnCaseAllCapital:
-			return strings.ToUpper(macroText)
-		}
+// macro returns the text of the macro named by a key, in the case of the key with IBautoCapitalizeMacro
+func (e *IBusBambooEngine) macro(key string) string {
+	if e.config.IBflags&IBmacroEnabled == 0 {
+		return ""
 	}
-	return macroText
+	return expandMacro(e.lookupMacro(key), key, e.config.IBflags&IBautoCapitalizeMacro != 0)
 }
 
 func (e *IBusBambooEngine) updatePreedit(processedStr string) {
@@ -133,66 +141,261 @@ func (e *IBusBambooEngine) updatePreedit(processedStr string) {
 	} else {
 		ibusText.AppendAttr(ibus.IBUS_ATTR_TYPE_UNDERLINE, ibus.IBUS_ATTR_UNDERLINE_SINGLE, 0, preeditLen)
 	}
-	e.UpdatePreeditTextWithMode(ibusText, preeditLen, true, ibus.IBUS_ENGINE_PREEDIT_COMMIT)
+	var preeditMode = ibus.IBUS_ENGINE_PREEDIT_COMMIT
+	if e.isCompositionKept() {
+		// the word is kept by the engine on focus out, the application must not commit it
+		preeditMode = ibus.IBUS_ENGINE_PREEDIT_CLEAR
+	}
+	e.UpdatePreeditTextWithMode(ibusText, preeditLen, true, preeditMode)
+	if e.config.IBflags&IBspellDiagnosisEnabled != 0 {
+		e.updateSpellDiagnosis()
+	}
 
 	if e.config.IBflags&IBmouseCapturing != 0 {
 		mouseCaptureUnlock()
 	}
 }
 
-func (e *IBusBambooEngine) getBambooInputMode() bamboo.Mode {
-	if e.shouldFallbackToEnglish(false) {
-		return bamboo.EnglishMode
-	}
-	return bamboo.VietnameseMode
+const (
+	// maxKeptCompositions bounds the words kept for the input contexts which lost the focus
+	maxKeptCompositions = 16
+	// keptCompositionTimeout is how long a kept word holds its place when there is no room left
+	keptCompositionTimeout = time.Hour
+)
+
+// compositionKey is the input context a word was typed in, an engine may serve several windows
+type compositionKey struct {
+	engine  *IBusBambooEngine
+	wmClass string
 }
 
-func (e *IBusBambooEngine) shouldFallbackToEnglish(checkVnRune bool) bool {
-	if e.config.IBflags&IBautoNonVnRestore == 0 {
-		return false
+type keptComposition struct {
+	snapshot bamboo.Snapshot
+	keptAt   time.Time
+}
+
+// keptCompositions are the words taken away from the input contexts that lost the focus, each input
+// context gets its own word back on focus in
+var keptCompositions = struct {
+	sync.Mutex
+	words map[compositionKey]keptComposition
+}{words: map[compositionKey]keptComposition{}}
+
+func (e *IBusBambooEngine) compositionKey() compositionKey {
+	return compositionKey{e, e.getWmClass()}
+}
+
+// canKeepComposition tells if the word of the input context has a place to be kept on focus out,
+// a word kept for too long gives its place when there is no room left
+func canKeepComposition(key compositionKey) bool {
+	if _, ok := keptCompositions.words[key]; ok || len(keptCompositions.words) < maxKeptCompositions {
+		return true
 	}
-	var vnSeq = e.getProcessedString(bamboo.VietnameseMode | bamboo.LowerCase)
-	var vnRunes = []rune(vnSeq)
-	if len(vnRunes) == 0 {
-		return false
+	var oldest compositionKey
+	var oldestTime time.Time
+	for k, kept := range keptCompositions.words {
+		if oldestTime.IsZero() || kept.keptAt.Before(oldestTime) {
+			oldest, oldestTime = k, kept.keptAt
+		}
 	}
-	if ok, _ := e.getMacroText(); ok {
+	if time.Since(oldestTime) < keptCompositionTimeout {
 		return false
 	}
-	// we want to allow dd even in non-vn sequence, because dd is used a lot in abbreviation
-	if e.config.IBflags&IBddFreeStyle != 0 && (vnRunes[len(vnRunes)-1] == 'd' || strings.ContainsRune(vnSeq, 'đ')) {
+	log.Printf("Drop the composition kept since %s\n", oldestTime.Format(time.Kitchen))
+	delete(keptCompositions.words, oldest)
+	return true
+}
+
+// isCompositionKept tells if the word being typed is kept by the engine on focus out, otherwise the
+// application commits it
+func (e *IBusBambooEngine) isCompositionKept() bool {
+	if _, ok := e.preeditor.(bamboo.ISnapshotter); !ok || e.config.IBflags&IBresumeComposition == 0 {
 		return false
 	}
-	if checkVnRune && !bamboo.HasAnyVietnameseRune(vnSeq) {
-		return false
+	keptCompositions.Lock()
+	defer keptCompositions.Unlock()
+	return canKeepComposition(e.compositionKey())
+}
+
+// keepComposition takes the word being typed away from the input context which loses the focus, it
+// is given back by resumeComposition when the same field is focused again
+func (e *IBusBambooEngine) keepComposition() {
+	if e.config.IBflags&IBresumeComposition == 0 || !e.checkInputMode(preeditIM) || e.getRawKeyLen() == 0 {
+		return
+	}
+	var snapshotter, ok = e.preeditor.(bamboo.ISnapshotter)
+	var key = e.compositionKey()
+	keptCompositions.Lock()
+	if ok = ok && canKeepComposition(key); ok {
+		keptCompositions.words[key] = keptComposition{snapshotter.Snapshot(), time.Now()}
+	}
+	keptCompositions.Unlock()
+	if !ok {
+		// there is no room left, the word is committed rather than lost
+		e.commitPreedit(e.getPreeditString())
+		return
+	}
+	e.HidePreeditText()
+	e.preeditor.Reset()
+}
+
+func (e *IBusBambooEngine) resumeComposition() {
+	var key = e.compositionKey()
+	keptCompositions.Lock()
+	var kept, ok = keptCompositions.words[key]
+	delete(keptCompositions.words, key)
+	keptCompositions.Unlock()
+	var snapshotter, canRestore = e.preeditor.(bamboo.ISnapshotter)
+	if !ok || !canRestore {
+		return
+	}
+	if e.getRawKeyLen() > 0 {
+		e.commitPreedit(e.getPreeditString())
+	}
+	if err := snapshotter.Restore(kept.snapshot); err != nil {
+		log.Println(err)
+		return
+	}
+	if e.config.IBflags&IBresumeComposition == 0 || !e.checkInputMode(preeditIM) {
+		// the word can no longer be shown as a preedit, it is given back to the application
+		e.commitPreedit(e.getPreeditString())
+		return
 	}
-	return !e.preeditor.IsValid(false)
+	e.updatePreedit(e.getPreeditString())
+}
+
+// updateSpellDiagnosis shows why the word being typed is not a valid syllable, which helps to debug
+// the spelling rules
+func (e *IBusBambooEngine) updateSpellDiagnosis() {
+	var checker, ok = e.preeditor.(bamboo.ISyllableChecker)
+	if !ok {
+		return
+	}
+	var d = checker.Diagnose(false)
+	if d.Verdict == bamboo.VerdictValid {
+		e.spellDiagnosis = ""
+	} else {
+		e.spellDiagnosis = d.String()
+	}
+	e.updateAuxiliaryText()
+}
+
+// updateAuxiliaryText shows the spell diagnosis and the typing hint, which share the auxiliary text
+func (e *IBusBambooEngine) updateAuxiliaryText() {
+	var texts []string
+	for _, text := range []string{e.spellDiagnosis, e.hint} {
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		e.HideAuxiliaryText()
+		return
+	}
+	e.UpdateAuxiliaryText(ibus.NewText(strings.Join(texts, "  |  ")), true)
+}
+
+func (e *IBusBambooEngine) getBambooInputMode() bamboo.Mode {
+	return e.wordComposer().InputMode()
+}
+
+func (e *IBusBambooEngine) shouldFallbackToEnglish(checkVnRune bool) bool {
+	return e.wordComposer().ShouldRestore(checkVnRune)
 }
 
 func (e *IBusBambooEngine) mustFallbackToEnglish() bool {
-	if e.config.IBflags&IBautoNonVnRestore == 0 {
-		return false
+	return e.restorePolicy().MustRestore(e.preeditor)
+}
+
+func (e *IBusBambooEngine) restorePolicy() bamboo.RestorePolicy {
+	return newRestorePolicy(e.config, e.inDictionary)
+}
+
+// wordComposer shares the word handling of the preedit with Transliterate
+func (e *IBusBambooEngine) wordComposer() *bamboo.WordComposer {
+	return &bamboo.WordComposer{
+		Engine: e.preeditor,
+		Policy: e.restorePolicy(),
+		Macro:  e.macro,
 	}
-	var vnSeq = e.getProcessedString(bamboo.VietnameseMode | bamboo.LowerCase)
-	var vnRunes = []rune(vnSeq)
-	if len(vnRunes) == 0 {
-		return false
+}
+
+// newRestorePolicy tells when the words are restored to their key strokes with the flags of a
+// config, the convert command shares it with the engine
+func newRestorePolicy(c *Config, inDictionary func(string) bool) bamboo.RestorePolicy {
+	var policy = bamboo.RestorePolicy{
+		AutoRestore: c.IBflags&IBautoNonVnRestore != 0,
+		DdFreeStyle: c.IBflags&IBddFreeStyle != 0,
+		PreferEnglish: func(raw, vnSeq string) bool {
+			return c.IBflags&IBenglishDictEnabled != 0 && preferEnglishWord(raw, vnSeq, c.EnglishBias, inDictionary(vnSeq))
+		},
 	}
-	// we want to allow dd even in non-vn sequence, because dd is used a lot in abbreviation
-	if e.config.IBflags&IBddFreeStyle != 0 && strings.ContainsRune(vnSeq, 'đ') {
+	if c.IBflags&IBspellCheckWithDicts != 0 {
+		policy.InDictionary = inDictionary
+	}
+	return policy
+}
+
+func preferEnglishWord(raw, vnSeq string, bias int, isVnWord bool) bool {
+	if raw == vnSeq || !englishDictionary.Has(raw) {
 		return false
 	}
-	if e.config.IBflags&IBspellCheckWithDicts != 0 {
-		return !dictionary[vnSeq]
+	switch bias {
+	case EnglishBiasHigh:
+		return true
+	case EnglishBiasLow:
+		if len(raw) < 4 {
+			return false
+		}
+	}
+	return !isVnWord
+}
+
+func (e *IBusBambooEngine) loadEnglishDictionary() {
+	if englishDictionary.Len() == 0 {
+		if d, err := loadDictionary(DictEnglish); err == nil {
+			englishDictionary = d
+		} else {
+			log.Println(err)
+		}
+	}
+	if dictionary.Len() == 0 {
+		e.loadDictionaries()
+	}
+}
+
+func (e *IBusBambooEngine) loadDictionaries() {
+	if d, err := loadSpellingDictionary(e.config, e.engineName); err == nil {
+		dictionary = d
+	} else {
+		log.Println(err)
 	}
-	return !e.preeditor.IsValid(true)
 }
 
-func (e *IBusBambooEngine) getComposedString(oldText string) string {
-	if bamboo.HasAnyVietnameseRune(oldText) && e.mustFallbackToEnglish() {
-		return e.getProcessedString(bamboo.EnglishMode)
+// loadSpellingDictionary merges the system dictionary, the extra ones from the config and the personal one
+func loadSpellingDictionary(c *Config, engineName string) (*Dictionary, error) {
+	var files = append(append([]string{}, c.DictionaryFiles...), getPersonalDictPath(engineName))
+	return loadDictionary(DictVietnameseCm, files...)
+}
+
+func (e *IBusBambooEngine) inDictionary(word string) bool {
+	return dictionary.Has(word)
+}
+
+func (e *IBusBambooEngine) isRejectedByDictionary(text string) bool {
+	return e.config.IBflags&IBspellCheckWithDicts != 0 && bamboo.HasAnyVietnameseRune(text) && e.mustFallbackToEnglish()
+}
+
+func (e *IBusBambooEngine) overrideDictionary(word string) {
+	if e.personalDict == nil {
+		return
+	}
+	if learned, err := e.personalDict.Override(word); err != nil {
+		log.Println(err)
+	} else if learned {
+		dictionary.Add(word)
+		log.Printf("Learned word [%s]\n", word)
 	}
-	return oldText
 }
 
 func (e *IBusBambooEngine) encodeText(text string) string {
@@ -204,10 +407,7 @@ func (e *IBusBambooEngine) getProcessedString(mode bamboo.Mode) string {
 }
 
 func (e *IBusBambooEngine) getPreeditString() string {
-	if e.shouldFallbackToEnglish(true) {
-		return e.getProcessedString(bamboo.EnglishMode)
-	}
-	return e.getProcessedString(bamboo.VietnameseMode)
+	return e.wordComposer().PreeditString()
 }
 
 func (e *IBusBambooEngine) resetPreedit() {
@@ -219,6 +419,10 @@ func (e *IBusBambooEngine) commitPreedit(s string) {
 	e.commitText(s)
 	e.HidePreeditText()
 	e.preeditor.Reset()
+	if e.config.IBflags&IBspellDiagnosisEnabled != 0 {
+		e.spellDiagnosis = ""
+		e.updateAuxiliaryText()
+	}
 }
 
 func (e *IBusBambooEngine) commitText(str string) {
@@ -234,7 +438,3 @@ func (e *IBusBambooEngine) commitText(str string) {
 func (e *IBusBambooEngine) getVnSeq() string {
 	return e.preeditor.GetProcessedString(bamboo.VietnameseMode)
 }
-
-func (e *IBusBambooEngine) hasMacroKey(key string) bool {
-	return e.macroTable.GetText(key) != ""
-}
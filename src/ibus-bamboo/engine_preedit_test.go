@@ -0,0 +1,203 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+	"github.com/BambooEngine/goibus/ibus"
+)
+
+func TestResumeComposition(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	// every input context, e.g. a text field, has its own engine
+	var field = newTestEngine(r, im, IBstdFlags&^IBmouseCapturing|IBresumeComposition)
+	var otherField = newTestEngine(r, im, IBstdFlags&^IBmouseCapturing|IBresumeComposition)
+	var typeKeys = func(e *IBusBambooEngine, keys string) {
+		for _, key := range keys {
+			e.ProcessKeyEvent(uint32(key), 0, 0)
+		}
+		r.sync()
+	}
+	field.FocusIn()
+	typeKeys(field, "vieej")
+	if r.preedit != "việ" || r.preeditMode != ibus.IBUS_ENGINE_PREEDIT_CLEAR {
+		t.Errorf("Preedit [vieej], got [%s] mode %d expected [việ] mode %d", r.preedit, r.preeditMode, ibus.IBUS_ENGINE_PREEDIT_CLEAR)
+	}
+	field.FocusOut()
+	r.sync()
+	if r.preedit != "" || field.getRawKeyLen() != 0 {
+		t.Errorf("Keep the composition on focus out, got the preedit [%s] expected []", r.preedit)
+	}
+	field.FocusIn()
+	r.sync()
+	if r.preedit != "việ" {
+		t.Errorf("Resume the composition on focus in, got the preedit [%s] expected [việ]", r.preedit)
+	}
+	typeKeys(field, "t ")
+	if r.committed.String() != "việt " {
+		t.Errorf("Resume the composition, got [%s] expected [việt ]", r.committed.String())
+	}
+	r.committed.Reset()
+
+	// each field keeps its own word while another field is focused
+	typeKeys(field, "vieej")
+	field.FocusOut()
+	otherField.FocusIn()
+	typeKeys(otherField, "ddi ")
+	typeKeys(otherField, "nam")
+	otherField.FocusOut()
+	if r.committed.String() != "đi " {
+		t.Errorf("Type in another field, got [%s] expected [đi ]", r.committed.String())
+	}
+	r.committed.Reset()
+	field.FocusIn()
+	typeKeys(field, "t ")
+	if r.committed.String() != "việt " {
+		t.Errorf("Focus the first field again, got [%s] expected [việt ]", r.committed.String())
+	}
+	field.FocusOut()
+	r.committed.Reset()
+	otherField.FocusIn()
+	typeKeys(otherField, " ")
+	if r.committed.String() != "nam " {
+		t.Errorf("Focus the other field again, got [%s] expected [nam ]", r.committed.String())
+	}
+	otherField.FocusOut()
+}
+
+func TestResumeCompositionWithoutRoom(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var fields []*IBusBambooEngine
+	for i := 0; i <= maxKeptCompositions; i++ {
+		var e = newTestEngine(r, im, IBstdFlags&^IBmouseCapturing|IBresumeComposition)
+		e.FocusIn()
+		for _, key := range "vieej" {
+			e.ProcessKeyEvent(uint32(key), 0, 0)
+		}
+		r.sync()
+		e.FocusOut()
+		fields = append(fields, e)
+	}
+	r.sync()
+	// the last field finds no room, its word is committed instead of cleared by the application
+	if r.preeditMode != ibus.IBUS_ENGINE_PREEDIT_COMMIT || r.committed.String() != "việ" {
+		t.Errorf("Focus out without room, got [%s] mode %d expected [việ] mode %d", r.committed.String(), r.preeditMode, ibus.IBUS_ENGINE_PREEDIT_COMMIT)
+	}
+	r.committed.Reset()
+	for _, e := range fields[:maxKeptCompositions] {
+		e.FocusIn()
+		e.ProcessKeyEvent(IBusSpace, 0, 0)
+		e.FocusOut()
+	}
+	r.sync()
+	if s, expected := r.committed.String(), strings.Repeat("việ ", maxKeptCompositions); s != expected || len(keptCompositions.words) != 0 {
+		t.Errorf("Resume every kept word, got [%s] expected [%s]", s, expected)
+	}
+}
+
+func TestShiftSpaceOnRejectedWord(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var tests = []struct {
+		ibFlags  uint
+		expected string
+	}{
+		// the empty dictionary rejects every word, Shift+Space keeps it
+		{IBspellCheckWithDicts | IBautoNonVnRestore, "việt "},
+		{IBspellCheckWithDicts | IBautoNonVnRestore | IBrestoreKeyStrokesEnabled, "vieejt"},
+	}
+	dir, err := ioutil.TempDir("", "ibus-bamboo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i, test := range tests {
+		var e = newTestEngine(r, im, test.ibFlags)
+		e.personalDict = NewPersonalDictionary(filepath.Join(dir, fmt.Sprintf("dict%d.txt", i)), filepath.Join(dir, fmt.Sprintf("dict%d.learning", i)))
+		r.committed.Reset()
+		for _, key := range "vieejt" {
+			e.ProcessKeyEvent(uint32(key), 0, 0)
+		}
+		e.ProcessKeyEvent(IBusSpace, 0, IBusShiftMask)
+		r.sync()
+		if s := r.committed.String(); s != test.expected {
+			t.Errorf("Shift+Space on [vieejt] with the flags %b, got [%s] expected [%s]", test.ibFlags, s, test.expected)
+		}
+		// both keeping the word and restoring its key strokes count as an override
+		if n := e.personalDict.overrides["việt"]; n != 1 {
+			t.Errorf("Shift+Space on [vieejt] with the flags %b, got %d overrides of [việt] expected 1", test.ibFlags, n)
+		}
+	}
+}
+
+func TestPreeditMacros(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var e = newTestEngine(r, im, IBstdFlags&^IBmouseCapturing|IBmacroEnabled|IBautoCapitalizeMacro)
+	e.macroTable.mTable = parseMacroTable(strings.NewReader("btw:by the way\nko:không\n"))
+	for _, key := range "btw, tooi ko owr ddaay BTW" {
+		// the keys that the engine does not handle are typed in the application
+		if handled, _ := e.ProcessKeyEvent(uint32(key), 0, 0); !handled {
+			r.sync()
+			r.committed.WriteRune(key)
+		}
+	}
+	if handled, _ := e.ProcessKeyEvent(IBusTab, 0, 0); !handled {
+		t.Errorf("Expand a macro with Tab, expected the key to be handled")
+	}
+	r.sync()
+	// the same text is transliterated with the macros of the engine
+	var expected = "by the way, tôi không ở đây BY THE WAY"
+	if r.committed.String() != expected {
+		t.Errorf("Type macros, got [%s] expected [%s]", r.committed.String(), expected)
+	}
+	var c = &bamboo.WordComposer{
+		Engine: bamboo.NewEngine(im, bamboo.EstdFlags),
+		Policy: e.restorePolicy(),
+		Macro:  e.macro,
+	}
+	if s := c.Transliterate("btw, tooi ko owr ddaay BTW"); s != expected {
+		t.Errorf("Transliterate macros, got [%s] expected [%s]", s, expected)
+	}
+}
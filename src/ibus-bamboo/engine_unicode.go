@@ -0,0 +1,152 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"github.com/BambooEngine/goibus/ibus"
+	"github.com/godbus/dbus"
+)
+
+const UnicodeMaxPageSize = 9
+
+func (e *IBusBambooEngine) openUnicodeList() {
+	e.hidePredictions()
+	if unicodeNames.IsEmpty() {
+		loadUnicodeNames()
+	}
+	e.unicode.Reset()
+	e.UpdatePreeditTextWithMode(ibus.NewText(""), 0, true, ibus.IBUS_ENGINE_PREEDIT_COMMIT)
+	e.UpdateAuxiliaryText(ibus.NewText("U+"), true)
+	lt := ibus.NewLookupTable()
+	lt.Orientation = IBusOrientationHorizontal
+	lt.PageSize = uint32(UnicodeMaxPageSize)
+	e.unicodeLookupTable = lt
+	e.updateUnicodeLookupTable()
+}
+
+func (e *IBusBambooEngine) unicodeProcessKeyEvent(keyVal uint32, keyCode uint32, state uint32) (bool, *dbus.Error) {
+	var raw = e.unicode.GetRawString()
+	var keyRune = rune(keyVal)
+	if keyVal == IBusReturn {
+		if len(e.unicodeLookupTable.Candidates) > 0 {
+			e.commitUnicodeCandidate()
+		} else if raw != "" {
+			e.CommitText(ibus.NewText(raw))
+		}
+		e.closeUnicodeCandidates()
+		return true, nil
+	}
+	if keyVal == IBusEscape {
+		e.closeUnicodeCandidates()
+		return true, nil
+	}
+	if keyVal == IBusLeft || keyVal == IBusUp {
+		e.CursorUp()
+		return true, nil
+	} else if keyVal == IBusRight || keyVal == IBusDown {
+		e.CursorDown()
+		return true, nil
+	} else if keyVal == IBusPageUp {
+		e.PageUp()
+		return true, nil
+	} else if keyVal == IBusPageDown {
+		e.PageDown()
+		return true, nil
+	}
+	if keyVal == IBusBackSpace {
+		if raw == "" {
+			e.closeUnicodeCandidates()
+			return true, nil
+		}
+		e.unicode.RemoveLastKey()
+	} else if keyRune >= ' ' && keyRune <= '~' {
+		// digits belong to code points, so candidates can't be chosen by number
+		e.unicode.ProcessKey(keyRune)
+	} else {
+		if raw != "" {
+			e.CommitText(ibus.NewText(raw))
+		}
+		e.closeUnicodeCandidates()
+		return false, nil
+	}
+	lt := ibus.NewLookupTable()
+	lt.Orientation = IBusOrientationHorizontal
+	for _, c := range e.unicode.Query() {
+		lt.AppendCandidate(c)
+	}
+	lt.PageSize = uint32(UnicodeMaxPageSize)
+	e.unicodeLookupTable = lt
+	e.updateUnicodeLookupTable()
+	return true, nil
+}
+
+func (e *IBusBambooEngine) updateCursorPosInUnicodeTable(idx uint32) bool {
+	pageSize := e.unicodeLookupTable.PageSize
+	if idx > pageSize {
+		return false
+	}
+	page := e.unicodeLookupTable.CursorPos / pageSize
+	newPos := page*pageSize + idx
+	if int(newPos) >= len(e.unicodeLookupTable.Candidates) {
+		return false
+	}
+	e.unicodeLookupTable.CursorPos = newPos
+	return true
+}
+
+func (e *IBusBambooEngine) updateUnicodeLookupTable() {
+	var visible = len(e.unicodeLookupTable.Candidates) > 0
+	e.UpdateLookupTable(e.unicodeLookupTable, visible)
+	var raw = e.unicode.GetRawString()
+	var cs = e.unicode.Query()
+	if pos := e.unicodeLookupTable.CursorPos; pos < uint32(len(cs)) {
+		var c = cs[pos]
+		e.UpdatePreeditTextWithMode(ibus.NewText(c), uint32(len([]rune(c))), true, ibus.IBUS_ENGINE_PREEDIT_COMMIT)
+		e.UpdateAuxiliaryText(ibus.NewText(raw+"  "+e.unicode.Describe(c)), true)
+	} else {
+		e.UpdatePreeditTextWithMode(ibus.NewText(raw), uint32(len([]rune(raw))), true, ibus.IBUS_ENGINE_PREEDIT_COMMIT)
+		var aux = raw
+		if aux == "" {
+			aux = "U+"
+		}
+		e.UpdateAuxiliaryText(ibus.NewText(aux), true)
+	}
+}
+
+func (e *IBusBambooEngine) commitUnicodeCandidate() {
+	var cs = e.unicode.Query()
+	if pos := e.unicodeLookupTable.CursorPos; pos < uint32(len(cs)) {
+		e.CommitText(ibus.NewText(cs[pos]))
+	}
+}
+
+func (e *IBusBambooEngine) refreshUnicodeCandidate() {
+	e.updateUnicodeLookupTable()
+}
+
+func (e *IBusBambooEngine) closeUnicodeCandidates() {
+	e.unicodeLookupTable = nil
+	e.unicode.Reset()
+	e.UpdateLookupTable(ibus.NewLookupTable(), true) // workaround for issue #18
+	e.HidePreeditText()
+	e.HideLookupTable()
+	e.HideAuxiliaryText()
+	e.isUnicodeLTOpened = false
+}
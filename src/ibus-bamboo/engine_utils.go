@@ -31,8 +31,11 @@ import (
 	"github.com/godbus/dbus"
 )
 
-var dictionary = map[string]bool{}
-var emojiTrie = NewTrie()
+var dictionary = NewDictionary(nil)
+var englishDictionary = NewDictionary(nil)
+var emojiTable = NewEmojiTable()
+var unicodeNames = NewUnicodeNameTable()
+var predictor = NewPredictor()
 
 func GetIBusEngineCreator() func(*dbus.Conn, string) dbus.ObjectPath {
 	go keyPressCapturing()
@@ -42,10 +45,9 @@ func GetIBusEngineCreator() func(*dbus.Conn, string) dbus.ObjectPath {
 		var engine = new(IBusBambooEngine)
 		var config = loadConfig(engineName)
 		var objectPath = dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/IBus/Engine/%s/%d", engineName, time.Now().UnixNano()))
-		var inputMethod = bamboo.ParseInputMethod(config.InputMethodDefinitions, config.InputMethod)
 		engine.Engine = ibus.BaseEngine(conn, objectPath)
 		engine.engineName = engineName
-		engine.preeditor = bamboo.NewEngine(inputMethod, config.Flags)
+		engine.preeditor = newPreeditor(config, engineName)
 		engine.config = loadConfig(engineName)
 		engine.propList = GetPropListByConfig(config)
 		ibus.PublishEngine(conn, objectPath, engine)
@@ -59,17 +61,26 @@ const KeypressDelayMs = 10
 
 func (e *IBusBambooEngine) init() {
 	e.emoji = NewEmojiEngine()
+	e.emoji.History = loadEmojiHistory(getEmojiHistoryPath(e.engineName))
+	e.unicode = NewUnicodeEngine()
+	e.personalDict = loadPersonalDictionary(e.engineName)
 	if e.macroTable == nil {
 		e.macroTable = NewMacroTable()
 		if e.config.IBflags&IBmacroEnabled != 0 {
 			e.macroTable.Enable(e.engineName)
 		}
 	}
-	if e.config.IBflags&IBspellCheckWithDicts != 0 && len(dictionary) == 0 {
-		dictionary, _ = loadDictionary(DictVietnameseCm)
+	if e.config.IBflags&(IBspellCheckWithDicts|IBautoCompleteEnabled) != 0 && dictionary.Len() == 0 {
+		e.loadDictionaries()
 	}
-	if e.config.IBflags&IBemojiDisabled == 0 && emojiTrie != nil && len(emojiTrie.Children) == 0 {
-		emojiTrie, _ = loadEmojiOne(DictEmojiOne)
+	if e.config.IBflags&IBenglishDictEnabled != 0 {
+		e.loadEnglishDictionary()
+	}
+	if e.config.IBflags&IBemojiDisabled == 0 && emojiTable.IsEmpty() {
+		loadEmojis()
+	}
+	if e.config.IBflags&IBautoCompleteEnabled != 0 && predictor.IsEmpty() {
+		loadPredictions()
 	}
 	keyPressHandler = e.keyPressHandler
 
@@ -93,6 +104,8 @@ func (e *IBusBambooEngine) init() {
 		defer mouseMutex.Unlock()
 		if e.isEmojiLTOpened {
 			e.refreshEmojiCandidate()
+		} else if e.isUnicodeLTOpened {
+			e.refreshUnicodeCandidate()
 		} else {
 			e.resetFakeBackspace()
 			e.resetBuffer()
@@ -209,6 +222,7 @@ func (e *IBusBambooEngine) getInputMode() int {
 }
 
 func (e *IBusBambooEngine) openLookupTable() {
+	e.hidePredictions()
 	var wmClasses = strings.Split(e.getWmClass(), ":")
 	var wmClass = e.getWmClass()
 	if len(wmClasses) == 2 {
@@ -319,19 +333,14 @@ func (e *IBusBambooEngine) isValidState(state uint32) bool {
 }
 
 func (e *IBusBambooEngine) getMacroText() (bool, string) {
-	if e.config.IBflags&IBmacroEnabled == 0 {
-		return false, ""
-	}
-	var text = e.preeditor.GetProcessedString(bamboo.VietnameseMode)
-	if e.macroTable.HasKey(strings.ToLower(text)) {
-		return true, e.expandMacro(text)
-	} else {
-		text = e.preeditor.GetProcessedString(bamboo.PunctuationMode)
-		if e.macroTable.HasKey(strings.ToLower(text)) {
-			return true, e.expandMacro(text)
-		}
-	}
-	return false, ""
+	var text, ok = e.wordComposer().MacroText()
+	return ok, text
+}
+
+// lookupMacro resolves a macro key against the focused window and the active input method
+func (e *IBusBambooEngine) lookupMacro(key string) string {
+	var text, _ = e.macroTable.Lookup(key, e.getWmClass(), e.config.InputMethod)
+	return text
 }
 
 func (e *IBusBambooEngine) getFakeBackspace() int {
@@ -0,0 +1,78 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+	"github.com/BambooEngine/goibus/ibus"
+)
+
+func TestPreferEnglishWord(t *testing.T) {
+	englishDictionary, _ = loadDictionary("../../" + DictEnglish)
+	dictionary, _ = loadDictionary("../../" + DictVietnameseCm)
+	var telex = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var tests = []struct {
+		keys     string
+		bias     int
+		expected bool
+	}{
+		{"class", EnglishBiasNormal, true},
+		{"worry", EnglishBiasNormal, true},
+		{"offer", EnglishBiasNormal, true},
+		{"viet", EnglishBiasNormal, false},
+		{"as", EnglishBiasNormal, false},
+		{"as", EnglishBiasHigh, true},
+		{"box", EnglishBiasNormal, false},
+		{"box", EnglishBiasHigh, true},
+		{"worry", EnglishBiasLow, true},
+		{"fox", EnglishBiasNormal, true},
+		{"fox", EnglishBiasLow, false},
+		{"chaof", EnglishBiasHigh, false},
+	}
+	for _, test := range tests {
+		var engine = bamboo.NewEngine(telex, bamboo.EstdFlags)
+		for _, key := range test.keys {
+			engine.ProcessKey(key, bamboo.VietnameseMode)
+		}
+		var raw = engine.GetProcessedString(bamboo.EnglishMode | bamboo.LowerCase)
+		var vnSeq = engine.GetProcessedString(bamboo.VietnameseMode | bamboo.LowerCase)
+		if ok := preferEnglishWord(raw, vnSeq, test.bias, dictionary.Has(vnSeq)); ok != test.expected {
+			t.Errorf("Prefer English word %s (%s) with bias %d, expected %v, got %v", test.keys, vnSeq, test.bias, test.expected, ok)
+		}
+	}
+}
+
+func TestEnglishBiasOfConfig(t *testing.T) {
+	var c Config
+	if c.EnglishBias != EnglishBiasNormal {
+		t.Errorf("English bias of a new config, got %d expected %d", c.EnglishBias, EnglishBiasNormal)
+	}
+	c.EnglishBias = EnglishBiasLow
+	var checked []string
+	for _, v := range GetEnglishBiasPropListByConfig(&c).PropertyList {
+		if prop := v.Value().(ibus.Property); prop.State == ibus.PROP_STATE_CHECKED {
+			checked = append(checked, prop.Key)
+		}
+	}
+	if len(checked) != 1 || checked[0] != "EnglishBias::-1" {
+		t.Errorf("English bias menu, got %v checked expected [EnglishBias::-1]", checked)
+	}
+}
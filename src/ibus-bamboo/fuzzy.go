@@ -0,0 +1,158 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	fuzzyExactScore       = 100
+	fuzzyPrefixScore      = 80
+	fuzzySubstringScore   = 60
+	fuzzySubsequenceScore = 40
+	fuzzyTypoScore        = 30
+)
+
+// fuzzyTokens splits a query into lowercase words, e.g. "heart_red" into [heart red].
+func fuzzyTokens(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	})
+}
+
+// fuzzyWordScore tells how well a query token matches a word, 0 if it doesn't. Exact
+// matches score the highest, then prefixes, substrings, subsequences ("thmbs" in "thumbs")
+// and finally words that are one or two typos away ("haert" for "heart").
+func fuzzyWordScore(token, word string) int {
+	if token == word {
+		return fuzzyExactScore
+	}
+	var tokenLen, wordLen = utf8.RuneCountInString(token), utf8.RuneCountInString(word)
+	if tokenLen == 0 || wordLen == 0 {
+		return 0
+	}
+	// a shorter token covering more of the word scores a bit higher
+	var coverage = 10 * tokenLen / wordLen
+	if strings.HasPrefix(word, token) {
+		return fuzzyPrefixScore + coverage
+	}
+	if tokenLen < 3 {
+		return 0
+	}
+	if strings.Contains(word, token) {
+		return fuzzySubstringScore + coverage
+	}
+	if isSubsequence(token, word) {
+		return fuzzySubsequenceScore + coverage
+	}
+	var maxEdits = 1
+	if tokenLen >= 8 {
+		maxEdits = 2
+	}
+	if tokenLen < 4 || abs(tokenLen-wordLen) > maxEdits {
+		return 0
+	}
+	if d := editDistance(token, word); d <= maxEdits {
+		return fuzzyTypoScore - 10*(d-1)
+	}
+	return 0
+}
+
+// fuzzyScore matches every token of a query against a list of words; all tokens must match.
+func fuzzyScore(tokens, words []string, cache map[string]int) int {
+	var total = 0
+	for _, token := range tokens {
+		var best = 0
+		for _, word := range words {
+			var key = token + "\x00" + word
+			score, ok := cache[key]
+			if !ok {
+				score = fuzzyWordScore(token, word)
+				cache[key] = score
+			}
+			if score > best {
+				best = score
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// isSubsequence checks whether the runes of s appear in t in the same order, starting with the first one.
+func isSubsequence(s, t string) bool {
+	var runes = []rune(s)
+	var i = 0
+	for j, r := range []rune(t) {
+		if j == 0 && r != runes[0] {
+			return false
+		}
+		if i < len(runes) && r == runes[i] {
+			i++
+		}
+	}
+	return i == len(runes)
+}
+
+// editDistance is the optimal string alignment distance, i.e. the Levenshtein distance
+// where swapping two adjacent runes counts as one edit.
+func editDistance(s, t string) int {
+	var a, b = []rune(s), []rune(t)
+	var d = make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			var cost = 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(minInt(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
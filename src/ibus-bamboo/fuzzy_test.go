@@ -0,0 +1,108 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFuzzyWordScore(t *testing.T) {
+	var tests = []struct {
+		token, word string
+		score       int
+	}{
+		{"heart", "heart", fuzzyExactScore},
+		{"hea", "heart", fuzzyPrefixScore + 6},
+		{"h", "heart", fuzzyPrefixScore + 2},
+		{"umb", "thumbs", fuzzySubstringScore + 5},
+		{"thmbs", "thumbs", fuzzySubsequenceScore + 8},
+		{"hmbs", "thumbs", 0},
+		{"heatr", "heart", fuzzyTypoScore},
+		{"haert", "heart", fuzzyTypoScore},
+		{"hert", "heart", fuzzySubsequenceScore + 8},
+		{"hexrt", "heart", fuzzyTypoScore},
+		{"ab", "cab", 0},
+		{"fier", "fire", fuzzyTypoScore},
+		{"xyzw", "fire", 0},
+	}
+	for _, test := range tests {
+		if score := fuzzyWordScore(test.token, test.word); score != test.score {
+			t.Errorf("Fuzzy score of %s in %s, expected %d, got %d", test.token, test.word, test.score, score)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	var tests = []struct {
+		s, t     string
+		distance int
+	}{
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"heart", "haert", 1},
+		{"cười", "cuời", 1},
+	}
+	for _, test := range tests {
+		if d := editDistance(test.s, test.t); d != test.distance {
+			t.Errorf("Edit distance of %s and %s, expected %d, got %d", test.s, test.t, test.distance, d)
+		}
+	}
+}
+
+func TestFuzzyFindEmoji(t *testing.T) {
+	loadTestEmojis()
+	var tests = []struct {
+		query    string
+		expected string
+	}{
+		{"thmbs", "👍"},
+		{"heart_red", "❤️"},
+		{"red heart", "❤️"},
+		{"haert red", "❤️"},
+		{"face melting", "🫠"},
+		{"mat cuoi", "😀"},
+	}
+	for _, test := range tests {
+		var cps = emojiTable.FuzzyFind(test.query)
+		if len(cps) == 0 || cps[0] != test.expected {
+			t.Errorf("Fuzzy find `%s`, expected %s first, got %v", test.query, test.expected, cps)
+		}
+	}
+	if cps := emojiTable.FuzzyFind("heart zzzzzz"); len(cps) != 0 {
+		t.Errorf("Fuzzy find `heart zzzzzz`, expected no match, got %v", cps)
+	}
+	// the order is stable
+	var first = fmt.Sprint(emojiTable.FuzzyFind("smile"))
+	for i := 0; i < 5; i++ {
+		if s := fmt.Sprint(emojiTable.FuzzyFind("smile")); s != first {
+			t.Errorf("Fuzzy find `smile`, expected a stable order, got %s and %s", first, s)
+		}
+	}
+	var be = NewEmojiEngine()
+	for _, key := range "thmbs" {
+		be.ProcessKey(key)
+	}
+	if cps := be.Query(); len(cps) == 0 || cps[0] != "👍" {
+		t.Errorf("Query `thmbs`, expected 👍 first, got %v", cps)
+	}
+	if !be.MatchString("heart red") {
+		t.Errorf("Match string `heart red`, expected true")
+	}
+}
@@ -58,6 +58,7 @@ const (
 )
 const (
 	IBusTab             = 0xff09
+	IBusLeftTab         = 0xfe20
 	IBusEnd             = 0xff57
 	IBusColon           = 0x03a
 	IBusLeft            = 0xFF51
@@ -0,0 +1,129 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+// an input method file in the config dir is named after its input method, e.g. "Telex W.im"
+const inputMethodFileExt = ".im"
+
+// getInputMethodDefinitions returns the input methods of the config with the ones of the input
+// method files over them
+func (c *Config) getInputMethodDefinitions() map[string]bamboo.InputMethodDefinition {
+	if len(c.InputMethodFiles) == 0 {
+		return c.InputMethodDefinitions
+	}
+	var defs = make(map[string]bamboo.InputMethodDefinition, len(c.InputMethodDefinitions)+len(c.InputMethodFiles))
+	for name, def := range c.InputMethodDefinitions {
+		defs[name] = def
+	}
+	for name, def := range c.InputMethodFiles {
+		defs[name] = def
+	}
+	return defs
+}
+
+func getInputMethodFilePaths(engineName string) []string {
+	var paths, _ = filepath.Glob(filepath.Join(getConfigDir(engineName), "*"+inputMethodFileExt))
+	return paths
+}
+
+// loadInputMethodFiles reads the input method files of the config dir, their issues are logged
+func loadInputMethodFiles(engineName string) map[string]bamboo.InputMethodDefinition {
+	var defs = map[string]bamboo.InputMethodDefinition{}
+	for _, path := range getInputMethodFilePaths(engineName) {
+		var def, issues, err = loadInputMethodFile(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		for _, issue := range issues {
+			log.Printf("%s: %s\n", path, issue)
+		}
+		defs[strings.TrimSuffix(filepath.Base(path), inputMethodFileExt)] = def
+	}
+	return defs
+}
+
+func loadInputMethodFile(path string) (bamboo.InputMethodDefinition, []bamboo.InputMethodIssue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	def, issues, err := bamboo.ParseInputMethodDefinition(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return def, issues, nil
+}
+
+// checkInputMethods lints input method files, or the input methods of the config and the files
+// in the config dir without arguments, e.g. `ibus-engine-bamboo check-im "Telex W.im"`
+func checkInputMethods(args []string, engineName string, out io.Writer) error {
+	var nIssues = 0
+	if len(args) == 0 {
+		var configPath = getConfigPath(engineName)
+		var c Config
+		if data, err := ioutil.ReadFile(configPath); err == nil {
+			if err := json.Unmarshal(data, &c); err != nil {
+				return fmt.Errorf("%s: %v", configPath, err)
+			}
+		}
+		var names []string
+		for name := range c.InputMethodDefinitions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, issue := range bamboo.LintInputMethod(c.InputMethodDefinitions[name]) {
+				fmt.Fprintf(out, "%s: %s: %s\n", configPath, name, issue)
+				nIssues++
+			}
+		}
+		args = getInputMethodFilePaths(engineName)
+	}
+	for _, path := range args {
+		var _, issues, err = loadInputMethodFile(path)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			fmt.Fprintf(out, "%s: %s\n", path, issue)
+		}
+		nIssues += len(issues)
+	}
+	if nIssues > 0 {
+		return fmt.Errorf("%d issues found", nIssues)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+func TestCheckInputMethods(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ibus-bamboo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var good, bad = filepath.Join(dir, "Telex W.im"), filepath.Join(dir, "Typo.im")
+	ioutil.WriteFile(good, []byte("s = DauSac\nw = UOA_ƯƠĂ__Ư\n\\# = _Ư\n"), 0644)
+	ioutil.WriteFile(bad, []byte("s = DauSac\nw = UOA_ƯƠĂ__\n"), 0644)
+	var out bytes.Buffer
+	if err := checkInputMethods([]string{good}, "bamboo", &out); err != nil || out.Len() > 0 {
+		t.Errorf("Check a valid input method, got %v %q", err, out.String())
+	}
+	if err := checkInputMethods([]string{good, bad}, "bamboo", &out); err == nil || out.String() != bad+`: line 2: key "w": unknown effect "UOA_ƯƠĂ__"`+"\n" {
+		t.Errorf("Check an input method with a typo, got %v %q", err, out.String())
+	}
+
+	def, _, err := loadInputMethodFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c = &Config{
+		InputMethod:            "Telex W",
+		InputMethodDefinitions: bamboo.GetInputMethodDefinitions(),
+		Flags:                  bamboo.EstdFlags,
+		InputMethodFiles:       map[string]bamboo.InputMethodDefinition{"Telex W": def},
+	}
+	if _, found := c.getInputMethodDefinitions()["Telex"]; !found {
+		t.Errorf("Input methods of a config with files, expected Telex")
+	}
+	var preeditor = newPreeditor(c, "bamboo")
+	preeditor.ProcessString("tuws", bamboo.VietnameseMode)
+	if s := preeditor.GetProcessedString(bamboo.VietnameseMode); s != "tứ" {
+		t.Errorf("Input method of a file, got [%s] expected [tứ]", s)
+	}
+}
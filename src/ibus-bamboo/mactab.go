@@ -22,6 +22,7 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -34,7 +35,19 @@ import (
 type MacroTable struct {
 	sync.RWMutex
 	enable bool
-	mTable map[string]string
+	mTable map[string][]macroEntry
+}
+
+// A macroScope limits a macro entry to some applications (matched against
+// the focused WM_CLASS) and/or some input methods. An empty list means any.
+type macroScope struct {
+	apps []string
+	ims  []string
+}
+
+type macroEntry struct {
+	text  string
+	scope macroScope
 }
 
 func NewMacroTable() *MacroTable {
@@ -48,8 +61,17 @@ func (e *MacroTable) LoadFromFile(macroFileName string) error {
 		return err
 	}
 	defer f.Close()
-	e.mTable = map[string]string{}
-	rd := bufio.NewReader(f)
+	e.mTable = parseMacroTable(f)
+	return nil
+}
+
+// parseMacroTable reads `key:text` lines. A section header such as
+// [app:code], [im:VNI] or [app:code im:Telex] limits the entries below it,
+// until the next header; [global] (or []) resets the scope.
+func parseMacroTable(r io.Reader) map[string][]macroEntry {
+	var table = map[string][]macroEntry{}
+	var scope macroScope
+	rd := bufio.NewReader(r)
 	for {
 		line, _, err := rd.ReadLine()
 		if err != nil {
@@ -59,27 +81,111 @@ func (e *MacroTable) LoadFromFile(macroFileName string) error {
 		if len(line) == 0 || strings.HasPrefix(s, ";") || strings.HasPrefix(s, "#") {
 			continue
 		}
+		if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+			scope = parseMacroScope(s[1 : len(s)-1])
+			continue
+		}
 		var list = strings.Split(s, ":")
 		if len(list) == 2 {
-			e.mTable[strings.ToLower(list[0])] = list[1]
+			var key = strings.ToLower(list[0])
+			table[key] = append(table[key], macroEntry{text: list[1], scope: scope})
 		}
 	}
-	return nil
+	return table
+}
+
+func parseMacroScope(header string) macroScope {
+	var scope macroScope
+	for _, field := range strings.Fields(header) {
+		var kv = strings.SplitN(field, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		var values = strings.Split(kv[1], ",")
+		switch strings.ToLower(kv[0]) {
+		case "app":
+			scope.apps = append(scope.apps, values...)
+		case "im":
+			scope.ims = append(scope.ims, values...)
+		}
+	}
+	return scope
+}
+
+func (s macroScope) matchApp(wmClass string) bool {
+	if len(s.apps) == 0 {
+		return true
+	}
+	var classes = strings.Split(wmClass, ":")
+	for _, app := range s.apps {
+		if strings.EqualFold(app, wmClass) {
+			return true
+		}
+		for _, cl := range classes {
+			if strings.EqualFold(app, cl) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s macroScope) matchIM(im string) bool {
+	if len(s.ims) == 0 {
+		return true
+	}
+	for _, name := range s.ims {
+		if strings.EqualFold(name, im) {
+			return true
+		}
+	}
+	return false
+}
+
+// specificity ranks scoped entries above global ones, with an application
+// scope taking precedence over an input method scope.
+func (s macroScope) specificity() int {
+	var n = 0
+	if len(s.apps) > 0 {
+		n += 2
+	}
+	if len(s.ims) > 0 {
+		n += 1
+	}
+	return n
+}
+
+//---------------------------------------------------------------
+func (e *MacroTable) Lookup(key, wmClass, im string) (string, bool) {
+	var text string
+	var best = -1
+	for _, entry := range e.mTable[strings.ToLower(key)] {
+		if entry.text == "" || !entry.scope.matchApp(wmClass) || !entry.scope.matchIM(im) {
+			continue
+		}
+		// later entries override earlier ones within the same specificity
+		if n := entry.scope.specificity(); n >= best {
+			best = n
+			text = entry.text
+		}
+	}
+	return text, best >= 0
 }
 
 //---------------------------------------------------------------
 func (e *MacroTable) GetText(key string) string {
-	return e.mTable[strings.ToLower(key)]
+	var text, _ = e.Lookup(key, "", "")
+	return text
 }
 
 //---------------------------------------------------------------
 func (e *MacroTable) HasKey(key string) bool {
-	return e.mTable[strings.ToLower(key)] != ""
+	return e.GetText(key) != ""
 }
 
 //---------------------------------------------------------------
 func (e *MacroTable) IncludeKey(key string) bool {
-	if e.mTable[key] != "" {
+	if len(e.mTable[key]) > 0 {
 		return true
 	}
 	for k := range e.mTable {
@@ -118,7 +224,7 @@ func (e *MacroTable) Enable(engineName string) {
 //---------------------------------------------------------------
 func (e *MacroTable) Disable() {
 	e.enable = false
-	e.mTable = map[string]string{}
+	e.mTable = map[string][]macroEntry{}
 }
 
 //---------------------------------------------------------------
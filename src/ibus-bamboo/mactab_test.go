@@ -0,0 +1,73 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const scopedMacroData = `
+vn:Việt Nam
+fn:fn
+[app:code,Code]
+fn:function
+[im:VNI]
+vn:VN
+[app:code im:VNI]
+fn:func
+[global]
+ko:không
+`
+
+func TestMacroScopes(t *testing.T) {
+	var mt = NewMacroTable()
+	mt.mTable = parseMacroTable(strings.NewReader(scopedMacroData))
+	var tests = []struct {
+		key, wmClass, im, expected string
+	}{
+		{"fn", "", "Telex", "fn"},
+		{"fn", "slack:Slack", "Telex", "fn"},
+		{"fn", "code:Code", "Telex", "function"},
+		{"fn", "code:Code", "VNI", "func"},
+		{"vn", "code:Code", "Telex", "Việt Nam"},
+		{"vn", "slack:Slack", "VNI", "VN"},
+		{"ko", "code:Code", "VNI", "không"},
+		{"FN", "code:Code", "Telex", "function"},
+	}
+	for _, test := range tests {
+		if text, _ := mt.Lookup(test.key, test.wmClass, test.im); text != test.expected {
+			t.Errorf("Lookup macro %s (%s, %s), expected %s, got %s", test.key, test.wmClass, test.im, test.expected, text)
+		}
+	}
+	if mt.GetText("fn") != "fn" {
+		t.Errorf("GetText fn, expected fn, got %s", mt.GetText("fn"))
+	}
+}
+
+func TestMacroScopeOnly(t *testing.T) {
+	var mt = NewMacroTable()
+	mt.mTable = parseMacroTable(strings.NewReader("[app:code]\nfn:function\n"))
+	if _, found := mt.Lookup("fn", "slack:Slack", "Telex"); found {
+		t.Errorf("Lookup macro fn outside of its scope, expected not found")
+	}
+	if mt.HasKey("fn") {
+		t.Errorf("HasKey fn for global scope, expected false")
+	}
+}
@@ -51,6 +51,24 @@ func main() {
 		isGnome = true
 	}
 	flag.Parse()
+	if flag.Arg(0) == "convert" {
+		var engineName = strings.ToLower(EngineName)
+		var config = loadConfig(engineName)
+		// the dictionaries are read from the data directory
+		os.Chdir(DataDir)
+		if err := convert(flag.Args()[1:], config, engineName, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.Arg(0) == "check-im" {
+		if err := checkInputMethods(flag.Args()[1:], strings.ToLower(EngineName), os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	if *embedded {
 		os.Chdir(DataDir)
 	}
@@ -0,0 +1,173 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the number of times a word rejected by the dictionary has to be kept before it gets learned
+const PersonalDictLearnThreshold = 2
+
+// PersonalDictionary holds the words that the user added to the spelling dictionary, one per line
+// in a plain text file, and counts how many times the user kept a rejected word.
+type PersonalDictionary struct {
+	path             string
+	overridesPath    string
+	modTime          time.Time
+	overridesModTime time.Time
+	words            *Dictionary
+	overrides        map[string]int
+}
+
+func NewPersonalDictionary(path, overridesPath string) *PersonalDictionary {
+	return &PersonalDictionary{
+		path:          path,
+		overridesPath: overridesPath,
+		words:         NewDictionary(nil),
+		overrides:     map[string]int{},
+	}
+}
+
+func getPersonalDictPath(engineName string) string {
+	return fmt.Sprintf(personalDictFile, getConfigDir(engineName), engineName)
+}
+
+func getPersonalDictOverridesPath(engineName string) string {
+	return fmt.Sprintf(dictLearningFile, getConfigDir(engineName), engineName)
+}
+
+func loadPersonalDictionary(engineName string) *PersonalDictionary {
+	var d = NewPersonalDictionary(getPersonalDictPath(engineName), getPersonalDictOverridesPath(engineName))
+	d.Reload()
+	return d
+}
+
+// Reload reads the dictionary file and the counts of the kept words again if they were modified,
+// e.g. by the user, and tells whether it did. The counts are read even if there is no dictionary file yet.
+func (d *PersonalDictionary) Reload() bool {
+	var reloaded bool
+	if sta, err := os.Stat(d.path); err == nil && !sta.ModTime().Equal(d.modTime) {
+		d.modTime = sta.ModTime()
+		if words, err := loadDictionary(d.path); err == nil {
+			d.words = words
+		}
+		reloaded = true
+	}
+	sta, err := os.Stat(d.overridesPath)
+	if err != nil || (!reloaded && sta.ModTime().Equal(d.overridesModTime)) {
+		return reloaded
+	}
+	d.overridesModTime = sta.ModTime()
+	d.overrides = map[string]int{}
+	if data, err := ioutil.ReadFile(d.overridesPath); err == nil {
+		var scanner = bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			var fields = strings.Fields(scanner.Text())
+			if len(fields) != 2 {
+				continue
+			}
+			if n, err := strconv.Atoi(fields[0]); err == nil && !d.words.Has(fields[1]) {
+				d.overrides[fields[1]] = n
+			}
+		}
+	}
+	return true
+}
+
+func (d *PersonalDictionary) Has(word string) bool {
+	return d.words.Has(word)
+}
+
+func (d *PersonalDictionary) Add(word string) error {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" || d.words.Has(word) {
+		return nil
+	}
+	d.words.Add(word)
+	delete(d.overrides, word)
+	f, err := os.OpenFile(d.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = f.WriteString(word + "\n"); err != nil {
+		return err
+	}
+	if sta, err := f.Stat(); err == nil {
+		d.modTime = sta.ModTime()
+	}
+	return d.saveOverrides()
+}
+
+// Override counts that the user kept a word that the dictionary rejected. The word is added to
+// the dictionary once it has been kept PersonalDictLearnThreshold times; Override tells whether
+// that happened.
+func (d *PersonalDictionary) Override(word string) (bool, error) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" || d.words.Has(word) {
+		return false, nil
+	}
+	d.overrides[word]++
+	if d.overrides[word] >= PersonalDictLearnThreshold {
+		return true, d.Add(word)
+	}
+	return false, d.saveOverrides()
+}
+
+func (d *PersonalDictionary) saveOverrides() error {
+	var words []string
+	for word := range d.overrides {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	var lines []string
+	for _, word := range words {
+		lines = append(lines, fmt.Sprintf("%d %s", d.overrides[word], word))
+	}
+	if err := ioutil.WriteFile(d.overridesPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return err
+	}
+	if sta, err := os.Stat(d.overridesPath); err == nil {
+		d.overridesModTime = sta.ModTime()
+	}
+	return nil
+}
+
+func OpenPersonalDictFile(engineName string) {
+	var path = getPersonalDictPath(engineName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		ioutil.WriteFile(path, []byte(personalDictHeader), 0644)
+	}
+	exec.Command("xdg-open", path).Start()
+}
+
+const personalDictHeader = `# Từ điển cá nhân: mỗi dòng một từ, ví dụ
+# ôkê
+# Các từ bị từ điển loại nhưng được giữ lại (Shift + Space) nhiều lần sẽ được tự động thêm vào đây.
+`
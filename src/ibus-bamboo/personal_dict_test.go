@@ -0,0 +1,62 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPersonalDictionary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ibus-bamboo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var path, learningPath = filepath.Join(dir, "dict.txt"), filepath.Join(dir, "dict.learning")
+	var d = NewPersonalDictionary(path, learningPath)
+	if learned, _ := d.Override("Ôkê"); learned || d.Has("ôkê") {
+		t.Errorf("Override ôkê once, expected the word not to be learned yet")
+	}
+	// the counts survive a restart, before the dictionary file exists
+	d = NewPersonalDictionary(path, learningPath)
+	if !d.Reload() || d.overrides["ôkê"] != 1 {
+		t.Errorf("Reload without the dictionary file, got the counts %v", d.overrides)
+	}
+	ioutil.WriteFile(path, []byte(personalDictHeader), 0644)
+	d.Reload()
+	if learned, err := d.Override("ôkê"); !learned || err != nil || !d.Has("Ôkê") {
+		t.Errorf("Override ôkê twice, expected the word to be learned, got %v %v", learned, err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != personalDictHeader+"ôkê\n" {
+		t.Errorf("Personal dictionary file, got %q", data)
+	}
+	// the file is edited by the user
+	time.Sleep(10 * time.Millisecond)
+	ioutil.WriteFile(path, []byte("# comment\nđắk\n"), 0644)
+	os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second))
+	d.Reload()
+	if !d.Has("đắk") || d.Has("ôkê") || d.Has("# comment") {
+		t.Errorf("Reload the personal dictionary, expected only đắk, got %v", d.words)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+const PredictionMaxCandidates = 9
+
+// Predictor proposes completions of the syllable being typed, found in a Dictionary by the syllables
+// without tones and marks so that "viet" finds "việt", "viết" and "viện", and the syllables that
+// usually follow the last one.
+type Predictor struct {
+	ranks   map[string]int      // how often a syllable occurs in the bigram list
+	bigrams map[string][]string // the syllables that follow a syllable, the most frequent first
+}
+
+func NewPredictor() *Predictor {
+	return &Predictor{
+		ranks:   map[string]int{},
+		bigrams: map[string][]string{},
+	}
+}
+
+func (p *Predictor) IsEmpty() bool {
+	return len(p.bigrams) == 0
+}
+
+func (p *Predictor) AddBigram(first, second string) {
+	first, second = strings.ToLower(first), strings.ToLower(second)
+	for _, s := range p.bigrams[first] {
+		if s == second {
+			return
+		}
+	}
+	p.bigrams[first] = append(p.bigrams[first], second)
+	p.ranks[first]++
+	p.ranks[second]++
+}
+
+func loadPredictor(bigramFile string) (*Predictor, error) {
+	var p = NewPredictor()
+	f, err := os.Open(bigramFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var fields = strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		p.AddBigram(fields[0], fields[1])
+	}
+	return p, scanner.Err()
+}
+
+func loadPredictions() {
+	var p, err = loadPredictor(DictVnBigrams)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	predictor = p
+}
+
+// Complete returns the dictionary syllables that start like the typed one. The key is the typed
+// syllable flattened with bamboo.ToneLess|bamboo.MarkLess|bamboo.LowerCase; the marks and the tone
+// that were already typed must be kept by the completions.
+func (p *Predictor) Complete(dict *Dictionary, key, typed string, limit int) []string {
+	if key == "" {
+		return nil
+	}
+	typed = strings.ToLower(typed)
+	var completions []string
+	var seen = map[string]bool{}
+	for _, word := range dict.FindByKeyPrefix(key, bamboo.ToneLess|bamboo.MarkLess) {
+		if word != typed && !seen[word] && keepsMarksAndTone(word, typed) {
+			seen[word] = true
+			completions = append(completions, word)
+		}
+	}
+	sort.SliceStable(completions, func(i, j int) bool {
+		var a, b = completions[i], completions[j]
+		if p.ranks[a] != p.ranks[b] {
+			return p.ranks[a] > p.ranks[b]
+		}
+		if len([]rune(a)) != len([]rune(b)) {
+			return len([]rune(a)) < len([]rune(b))
+		}
+		return a < b
+	})
+	if len(completions) > limit {
+		completions = completions[:limit]
+	}
+	return completions
+}
+
+// Next returns the syllables that usually follow prev.
+func (p *Predictor) Next(prev string, limit int) []string {
+	var next = p.bigrams[strings.ToLower(prev)]
+	if len(next) > limit {
+		next = next[:limit]
+	}
+	return next
+}
+
+func findTone(s string) bamboo.Tone {
+	for _, c := range s {
+		if tone := bamboo.FindToneFromChar(c); tone != bamboo.ToneNone {
+			return tone
+		}
+	}
+	return bamboo.ToneNone
+}
+
+func keepsMarksAndTone(word, typed string) bool {
+	var wordRunes = []rune(word)
+	for i, c := range []rune(typed) {
+		if i >= len(wordRunes) {
+			return false
+		}
+		var toneless = bamboo.AddToneToChar(c, 0)
+		if toneless != bamboo.AddMarkToChar(toneless, 0) && toneless != bamboo.AddToneToChar(wordRunes[i], 0) {
+			return false
+		}
+	}
+	var tone = findTone(typed)
+	return tone == bamboo.ToneNone || tone == findTone(word)
+}
+
+// matchCase writes a completion in the case of the typed text, e.g. "Việt" for "Vie".
+func matchCase(word, typed string) string {
+	var runes = []rune(typed)
+	if len(runes) == 0 || unicode.IsLower(runes[0]) {
+		return word
+	}
+	if len(runes) > 1 && determineMacroCase(typed) == VnCaseAllCapital {
+		return strings.ToUpper(word)
+	}
+	var wordRunes = []rune(word)
+	wordRunes[0] = unicode.ToUpper(wordRunes[0])
+	return string(wordRunes)
+}
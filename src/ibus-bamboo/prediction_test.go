@@ -0,0 +1,183 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
+)
+
+func TestPredictorComplete(t *testing.T) {
+	var p, err = loadPredictor("../../" + DictVnBigrams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict, err := loadDictionary("../../" + DictVietnameseCm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var completions = p.Complete(dict, "viet", "viet", PredictionMaxCandidates)
+	if len(completions) == 0 || completions[0] != "việt" {
+		t.Errorf("Complete viet, expected việt first, got %v", completions)
+	}
+	completions = p.Complete(dict, "nguoi", "ngươ", PredictionMaxCandidates)
+	if fmt.Sprint(completions) != "[người ngươi ngưởi]" {
+		t.Errorf("Complete ngươ, expected the marks to be kept, got %v", completions)
+	}
+	completions = p.Complete(dict, "vie", "viế", PredictionMaxCandidates)
+	for _, word := range completions {
+		if findTone(word) != findTone("viế") {
+			t.Errorf("Complete viế, expected the acute tone, got %s", word)
+		}
+	}
+	if completions = p.Complete(dict, "xyz", "xyz", PredictionMaxCandidates); len(completions) != 0 {
+		t.Errorf("Complete xyz, expected no completion, got %v", completions)
+	}
+	if completions = p.Complete(dict, "a", "a", 3); len(completions) != 3 {
+		t.Errorf("Complete a, expected 3 completions, got %v", completions)
+	}
+}
+
+func TestPredictorNext(t *testing.T) {
+	var p = NewPredictor()
+	p.AddBigram("việt", "nam")
+	p.AddBigram("chúng", "ta")
+	p.AddBigram("chúng", "tôi")
+	p.AddBigram("chúng", "ta")
+	if next := p.Next("Chúng", PredictionMaxCandidates); fmt.Sprint(next) != "[ta tôi]" {
+		t.Errorf("Next of chúng, expected [ta tôi], got %v", next)
+	}
+	if next := p.Next("nam", PredictionMaxCandidates); len(next) != 0 {
+		t.Errorf("Next of nam, expected nothing, got %v", next)
+	}
+}
+
+func TestMatchCase(t *testing.T) {
+	var tests = []struct {
+		word, typed, expected string
+	}{
+		{"việt", "vie", "việt"},
+		{"việt", "Vie", "Việt"},
+		{"việt", "VIE", "VIỆT"},
+		{"đường", "Đ", "Đường"},
+	}
+	for _, test := range tests {
+		if s := matchCase(test.word, test.typed); s != test.expected {
+			t.Errorf("Match case of %s with %s, expected %s, got %s", test.word, test.typed, test.expected, s)
+		}
+	}
+}
+
+func TestTypingHint(t *testing.T) {
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "VNI")
+	var cache keystrokesCache
+	for i := 0; i < 2; i++ {
+		var hint = cache.typingHint([]string{"người", "ta", "năm"}, im, bamboo.EstdFlags)
+		if hint != "người: ngu7o7i2  năm: na8m" {
+			t.Errorf("Typing hint, got [%s] expected [người: ngu7o7i2  năm: na8m]", hint)
+		}
+	}
+	if len(cache.keys) != 3 {
+		t.Errorf("Cache the key strokes of the hint, got %v", cache.keys)
+	}
+	// the key strokes of another input method are not reused
+	var telex = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	if hint := cache.typingHint([]string{"người"}, telex, bamboo.EstdFlags); hint != "người: nguwowif" || len(cache.keys) != 1 {
+		t.Errorf("Typing hint with Telex, got [%s] %v", hint, cache.keys)
+	}
+}
+
+func TestHidePredictions(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var e = newTestEngine(r, im, IBstdFlags)
+	e.unicode = NewUnicodeEngine()
+	for name, hide := range map[string]func(){
+		"FocusOut":        func() { e.FocusOut() },
+		"Reset":           func() { e.Reset() },
+		"openUnicodeList": e.openUnicodeList,
+	} {
+		e.showPredictions([]string{"người", "ta"})
+		hide()
+		if e.predictionLookupTable != nil || e.predictions != nil {
+			t.Errorf("Predictions after %s, got %v", name, e.predictions)
+		}
+		e.closeUnicodeCandidates()
+	}
+}
+
+func TestClearSuggestions(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var e = newTestEngine(r, im, IBstdFlags&^IBautoCompleteEnabled)
+	for _, key := range "tiengf" {
+		e.ProcessKeyEvent(uint32(key), 0, 0)
+	}
+	e.ProcessKeyEvent(IBusTab, 0, IBusShiftMask)
+	if len(e.predictions) == 0 {
+		t.Fatalf("Shift+Tab on [tièng], expected some suggestions")
+	}
+	e.ProcessKeyEvent('j', 0, 0)
+	if len(e.predictions) != 0 {
+		t.Errorf("Type a key after Shift+Tab, got the suggestions %v expected none", e.predictions)
+	}
+	e.ProcessKeyEvent(IBusTab, 0, 0)
+	r.sync()
+	if r.committed.String() != "tiẹng" {
+		t.Errorf("Tab after the suggestions are cleared, got [%s] expected the typed word [tiẹng]", r.committed.String())
+	}
+}
+
+func TestTypingHintWithSpellDiagnosis(t *testing.T) {
+	var r, err = newSignalRecorder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.conn.Close()
+	var im = bamboo.ParseInputMethod(bamboo.GetInputMethodDefinitions(), "Telex")
+	var e = newTestEngine(r, im, IBstdFlags&^IBautoNonVnRestore|IBspellDiagnosisEnabled|IBtypingHintEnabled)
+	for _, key := range "vieetc" {
+		e.ProcessKeyEvent(uint32(key), 0, 0)
+	}
+	r.sync()
+	var diagnosis = r.aux
+	if diagnosis == "" {
+		t.Fatalf("Spell diagnosis of [viêtc], expected an auxiliary text")
+	}
+	e.showPredictions([]string{"tiếng"})
+	r.sync()
+	if r.aux != diagnosis+"  |  tiếng: tieengs" {
+		t.Errorf("Typing hint with a spell diagnosis, got [%s]", r.aux)
+	}
+	e.hidePredictions()
+	r.sync()
+	if r.aux != diagnosis {
+		t.Errorf("Hide the predictions, got [%s] expected the spell diagnosis [%s]", r.aux, diagnosis)
+	}
+}
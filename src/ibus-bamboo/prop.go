@@ -46,6 +46,17 @@ const (
 	PropKeyAutoCapitalizeMacro  = "auto_capitalize_macro"
 	PropKeyIMQuickSwitchEnabled = "im_quick_switch"
 	PropKeyRestoreKeyStrokes    = "restore_key_strokes"
+	PropKeyUnicodePicker        = "unicode_picker"
+	PropKeyAutoComplete         = "auto_complete"
+	PropKeyPersonalDict         = "open_personal_dict"
+	PropKeyEnglishDict          = "english_dict"
+	PropKeySpellDiagnosis       = "spell_diagnosis"
+	PropKeyQuickTelex           = "quick_telex"
+	PropKeyQuickStartConsonant  = "quick_start_consonant"
+	PropKeyQuickEndConsonant    = "quick_end_consonant"
+	PropKeyTypingHint           = "typing_hint"
+	PropKeyResumeComposition    = "resume_composition"
+	PropKeyNormalizeToneStyle   = "normalize_tone_style"
 )
 
 var IBusSeparator = &ibus.Property{
@@ -166,6 +177,12 @@ func GetPropListByConfig(c *Config) *ibus.PropList {
 	)
 }
 
+// charsetTooltips tells the two decomposed forms of Unicode apart
+var charsetTooltips = map[string]string{
+	bamboo.UNICODE_NFD: "Tách cả dấu thanh lẫn dấu mũ, móc, trăng: ệ = e + dấu nặng + dấu mũ (như văn bản soạn trên macOS)",
+	"Unicode tổ hợp":   "Chỉ tách dấu thanh, giữ nguyên â ă ê ô ơ ư: ệ = ê + dấu nặng (như bảng mã tổ hợp trên Windows)",
+}
+
 func GetCharsetPropListByConfig(c *Config) *ibus.PropList {
 	var charsetProperties []*ibus.Property
 	charsetProperties = append(charsetProperties,
@@ -186,12 +203,16 @@ func GetCharsetPropListByConfig(c *Config) *ibus.PropList {
 		if charset == c.OutputCharset {
 			state = ibus.PROP_STATE_CHECKED
 		}
+		var tooltip = "OutputCharset: " + charset
+		if explanation, found := charsetTooltips[charset]; found {
+			tooltip += "\n" + explanation
+		}
 		var imProp = &ibus.Property{
 			Name:      "IBusProperty",
 			Key:       "OutputCharset::" + charset,
 			Type:      ibus.PROP_TYPE_RADIO,
 			Label:     dbus.MakeVariant(ibus.NewText(charset)),
-			Tooltip:   dbus.MakeVariant(ibus.NewText("OutputCharset: " + charset)),
+			Tooltip:   dbus.MakeVariant(ibus.NewText(tooltip)),
 			Sensitive: true,
 			Visible:   true,
 			State:     state,
@@ -219,7 +240,7 @@ func GetIMPropListByConfig(c *Config) *ibus.PropList {
 		},
 		IBusSeparator,
 	)
-	for im := range c.InputMethodDefinitions {
+	for im := range c.getInputMethodDefinitions() {
 		var state = ibus.PROP_STATE_UNCHECKED
 		if im == c.InputMethod {
 			state = ibus.PROP_STATE_CHECKED
@@ -305,6 +326,14 @@ func GetSpellCheckingPropListByConfig(c *Config) *ibus.PropList {
 	if c.IBflags&IBspellCheckWithDicts != 0 {
 		spellCheckByDicts = ibus.PROP_STATE_CHECKED
 	}
+	englishDict := ibus.PROP_STATE_UNCHECKED
+	if c.IBflags&IBenglishDictEnabled != 0 {
+		englishDict = ibus.PROP_STATE_CHECKED
+	}
+	spellDiagnosis := ibus.PROP_STATE_UNCHECKED
+	if c.IBflags&IBspellDiagnosisEnabled != 0 {
+		spellDiagnosis = ibus.PROP_STATE_CHECKED
+	}
 	return ibus.NewPropList(
 		&ibus.Property{
 			Name:      "IBusProperty",
@@ -343,9 +372,87 @@ func GetSpellCheckingPropListByConfig(c *Config) *ibus.PropList {
 			Symbol:    dbus.MakeVariant(ibus.NewText("O")),
 			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
 		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyEnglishDict,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Giữ nguyên từ tiếng Anh")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Không chuyển các từ có trong từ điển tiếng Anh")),
+			Sensitive: true,
+			Visible:   true,
+			State:     englishDict,
+			Symbol:    dbus.MakeVariant(ibus.NewText("E")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       "-",
+			Type:      ibus.PROP_TYPE_MENU,
+			Label:     dbus.MakeVariant(ibus.NewText("Mức giữ từ tiếng Anh")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Mức giữ từ tiếng Anh")),
+			Sensitive: c.IBflags&IBenglishDictEnabled != 0,
+			Visible:   true,
+			Symbol:    dbus.MakeVariant(ibus.NewText("")),
+			SubProps:  dbus.MakeVariant(GetEnglishBiasPropListByConfig(c)),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyPersonalDict,
+			Type:      ibus.PROP_TYPE_NORMAL,
+			Label:     dbus.MakeVariant(ibus.NewText("Mở từ điển cá nhân")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Mở từ điển cá nhân")),
+			Sensitive: true,
+			Visible:   true,
+			Symbol:    dbus.MakeVariant(ibus.NewText("P")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeySpellDiagnosis,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Giải thích lỗi chính tả (debug)")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Show why a syllable is invalid")),
+			Sensitive: true,
+			Visible:   true,
+			State:     spellDiagnosis,
+			Symbol:    dbus.MakeVariant(ibus.NewText("")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
 	)
 }
 
+func GetEnglishBiasPropListByConfig(c *Config) *ibus.PropList {
+	var biases = []struct {
+		bias    int
+		label   string
+		tooltip string
+	}{
+		{EnglishBiasLow, "Thấp", "Chỉ giữ các từ từ 4 chữ cái không phải tiếng Việt"},
+		{EnglishBiasNormal, "Vừa", "Giữ các từ không có trong từ điển tiếng Việt"},
+		{EnglishBiasHigh, "Cao", "Luôn giữ các từ tiếng Anh"},
+	}
+	var biasProperties []*ibus.Property
+	for _, b := range biases {
+		var state = ibus.PROP_STATE_UNCHECKED
+		if b.bias == c.EnglishBias {
+			state = ibus.PROP_STATE_CHECKED
+		}
+		biasProperties = append(biasProperties, &ibus.Property{
+			Name:      "IBusProperty",
+			Key:       "EnglishBias::" + strconv.Itoa(b.bias),
+			Type:      ibus.PROP_TYPE_RADIO,
+			Label:     dbus.MakeVariant(ibus.NewText(b.label)),
+			Tooltip:   dbus.MakeVariant(ibus.NewText(b.tooltip)),
+			Sensitive: true,
+			Visible:   true,
+			State:     state,
+			Symbol:    dbus.MakeVariant(ibus.NewText("")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		})
+	}
+	return ibus.NewPropList(biasProperties...)
+}
+
 func GetOptionsPropListByConfig(c *Config) *ibus.PropList {
 	// tone
 	toneStdChecked := ibus.PROP_STATE_UNCHECKED
@@ -363,12 +470,28 @@ func GetOptionsPropListByConfig(c *Config) *ibus.PropList {
 	if c.Flags&bamboo.EfreeToneMarking != 0 {
 		toneFreeMarkingChecked = ibus.PROP_STATE_CHECKED
 	}
+	quickTelexChecked := ibus.PROP_STATE_UNCHECKED
+	if c.Flags&bamboo.EquickTelex != 0 {
+		quickTelexChecked = ibus.PROP_STATE_CHECKED
+	}
+	quickStartConsonantChecked := ibus.PROP_STATE_UNCHECKED
+	if c.Flags&bamboo.EquickStartConsonant != 0 {
+		quickStartConsonantChecked = ibus.PROP_STATE_CHECKED
+	}
+	quickEndConsonantChecked := ibus.PROP_STATE_UNCHECKED
+	if c.Flags&bamboo.EquickEndConsonant != 0 {
+		quickEndConsonantChecked = ibus.PROP_STATE_CHECKED
+	}
 	if c.IBflags&IBpreeditInvisibility != 0 {
 		preeditInvisibilityChecked = ibus.PROP_STATE_CHECKED
 	}
 	if c.IBflags&IBpreeditElimination != 0 {
 		x11FakeBackspaceChecked = ibus.PROP_STATE_CHECKED
 	}
+	resumeCompositionChecked := ibus.PROP_STATE_UNCHECKED
+	if c.IBflags&IBresumeComposition != 0 {
+		resumeCompositionChecked = ibus.PROP_STATE_CHECKED
+	}
 
 	return ibus.NewPropList(
 		&ibus.Property{
@@ -395,6 +518,53 @@ func GetOptionsPropListByConfig(c *Config) *ibus.PropList {
 			Symbol:    dbus.MakeVariant(ibus.NewText("M")),
 			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
 		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyNormalizeToneStyle,
+			Type:      ibus.PROP_TYPE_NORMAL,
+			Label:     dbus.MakeVariant(ibus.NewText("Chuẩn hóa dấu thanh vùng chọn")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Đặt lại dấu thanh của đoạn văn bản được chọn (hoặc trong clipboard) theo kiểu dấu thanh đang dùng")),
+			Sensitive: true,
+			Visible:   true,
+			Symbol:    dbus.MakeVariant(ibus.NewText("M")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyQuickTelex,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Gõ nhanh phụ âm (cc, gg, kk, nn, pp, qq, tt)")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Quick Telex: cc -> ch, gg -> gi, kk -> kh, nn -> ng, pp -> ph, qq -> qu, tt -> th")),
+			Sensitive: true,
+			Visible:   true,
+			State:     quickTelexChecked,
+			Symbol:    dbus.MakeVariant(ibus.NewText("Q")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyQuickStartConsonant,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Phụ âm đầu nhanh (f, j, w)")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("f -> ph, j -> gi, w -> qu (w is ư in Telex 2 and Telex W)")),
+			Sensitive: true,
+			Visible:   true,
+			State:     quickStartConsonantChecked,
+			Symbol:    dbus.MakeVariant(ibus.NewText("Q")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyQuickEndConsonant,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Phụ âm cuối nhanh (g, h, k)")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("g -> ng, h -> nh, k -> ch")),
+			Sensitive: true,
+			Visible:   true,
+			State:     quickEndConsonantChecked,
+			Symbol:    dbus.MakeVariant(ibus.NewText("Q")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
 		&ibus.Property{
 			Name:      "IBusProperty",
 			Key:       PropKeyPreeditInvisibility,
@@ -407,6 +577,18 @@ func GetOptionsPropListByConfig(c *Config) *ibus.PropList {
 			Symbol:    dbus.MakeVariant(ibus.NewText("P")),
 			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
 		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyResumeComposition,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Giữ từ đang gõ khi chuyển cửa sổ")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Keep the word being typed in a window and resume it when the window is focused again")),
+			Sensitive: true,
+			Visible:   true,
+			State:     resumeCompositionChecked,
+			Symbol:    dbus.MakeVariant(ibus.NewText("P")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
 		&ibus.Property{
 			Name:      "IBusProperty",
 			Key:       PropKeyMouseCapturing,
@@ -451,13 +633,25 @@ func GetHotKeyPropListByConfig(c *Config) *ibus.PropList {
 	if c.IBflags&IBemojiDisabled != 0 {
 		emojiChecked = ibus.PROP_STATE_UNCHECKED
 	}
+	unicodePickerChecked := ibus.PROP_STATE_UNCHECKED
+	if c.IBflags&IBunicodePickerEnabled != 0 {
+		unicodePickerChecked = ibus.PROP_STATE_CHECKED
+	}
+	autoCompleteChecked := ibus.PROP_STATE_UNCHECKED
+	if c.IBflags&IBautoCompleteEnabled != 0 {
+		autoCompleteChecked = ibus.PROP_STATE_CHECKED
+	}
+	typingHintChecked := ibus.PROP_STATE_UNCHECKED
+	if c.IBflags&IBtypingHintEnabled != 0 {
+		typingHintChecked = ibus.PROP_STATE_CHECKED
+	}
 
 	return ibus.NewPropList(
 		&ibus.Property{
 			Name:      "IBusProperty",
 			Key:       PropKeyEmojiEnabled,
 			Type:      ibus.PROP_TYPE_TOGGLE,
-			Label:     dbus.MakeVariant(ibus.NewText("Emoji  [Shift + :]")),
+			Label:     dbus.MakeVariant(ibus.NewText("Emoji  [Shift + :], đổi màu da [Tab]")),
 			Tooltip:   dbus.MakeVariant(ibus.NewText("Emoji")),
 			Sensitive: true,
 			Visible:   true,
@@ -465,6 +659,42 @@ func GetHotKeyPropListByConfig(c *Config) *ibus.PropList {
 			Symbol:    dbus.MakeVariant(ibus.NewText(":)")),
 			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
 		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyUnicodePicker,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Ký tự Unicode  [Ctrl + Shift + :]")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Unicode characters by code point or name")),
+			Sensitive: true,
+			Visible:   true,
+			State:     unicodePickerChecked,
+			Symbol:    dbus.MakeVariant(ibus.NewText("U+")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyAutoComplete,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Gợi ý từ  [Tab]")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Word completion and prediction")),
+			Sensitive: true,
+			Visible:   true,
+			State:     autoCompleteChecked,
+			Symbol:    dbus.MakeVariant(ibus.NewText("")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
+		&ibus.Property{
+			Name:      "IBusProperty",
+			Key:       PropKeyTypingHint,
+			Type:      ibus.PROP_TYPE_TOGGLE,
+			Label:     dbus.MakeVariant(ibus.NewText("Hướng dẫn cách gõ từ gợi ý")),
+			Tooltip:   dbus.MakeVariant(ibus.NewText("Show the key strokes of the suggested words")),
+			Sensitive: true,
+			Visible:   true,
+			State:     typingHintChecked,
+			Symbol:    dbus.MakeVariant(ibus.NewText("")),
+			SubProps:  dbus.MakeVariant(*ibus.NewPropList()),
+		},
 		&ibus.Property{
 			Name:      "IBusProperty",
 			Key:       PropKeyIMQuickSwitchEnabled,
@@ -19,58 +19,158 @@
 
 package main
 
-type TrieNode struct {
-	isWord   bool
-	value    string
-	Children map[rune]*TrieNode
+import (
+	"sort"
+)
+
+type trieNode struct {
+	edges  int32 // the first child of the node in Trie.labels and Trie.children
+	nEdges int32
+	lo, hi int32 // the values of all the keys under the node are Trie.values[lo:hi]
+}
+
+// Trie is a prefix index stored in flat arrays. Nodes are numbered in depth-first order and
+// their children are sorted by rune, so the values of the keys that start with a prefix form
+// a contiguous, precomputed slice: a lookup costs one binary search per rune and no allocation.
+// Keys are collected by Insert and compacted by Build.
+type Trie struct {
+	pending  map[string][]string
+	nodes    []trieNode
+	labels   []rune
+	children []int32
+	values   []string
 }
 
-func NewTrie() *TrieNode {
-	var trie = new(TrieNode)
-	trie.Children = make(map[rune]*TrieNode)
-	return trie
+func NewTrie() *Trie {
+	return &Trie{pending: map[string][]string{}}
 }
 
-func InsertTrie(r *TrieNode, word, value string) {
-	var currentNode = r
-	for _, c := range word {
-		if cn := currentNode.Children[c]; cn != nil {
-			currentNode = cn
-		} else {
-			var n = new(TrieNode)
-			n.Children = make(map[rune]*TrieNode)
-			currentNode.Children[c] = n
-			currentNode = n
+func (t *Trie) IsEmpty() bool {
+	return len(t.pending) == 0 && (len(t.nodes) == 0 || t.nodes[0].hi == 0)
+}
+
+func (t *Trie) Insert(key, value string) {
+	if t.pending == nil {
+		t.unbuild()
+	}
+	for _, v := range t.pending[key] {
+		if v == value {
+			return
 		}
 	}
-	if currentNode.value == "" {
-		currentNode.value = value
-	} else {
-		currentNode.value += ":" + value
+	t.pending[key] = append(t.pending[key], value)
+}
+
+// Build compacts the inserted keys. The values under a node are sorted by key, then by value.
+func (t *Trie) Build() {
+	if t.pending == nil {
+		return
+	}
+	var keys = make([]string, 0, len(t.pending))
+	for key := range t.pending {
+		keys = append(keys, key)
 	}
-	currentNode.isWord = true
+	sort.Strings(keys)
+	var runes = make([][]rune, len(keys))
+	var values = make([][]string, len(keys))
+	for i, key := range keys {
+		runes[i] = []rune(key)
+		values[i] = t.pending[key]
+		sort.Strings(values[i])
+	}
+	t.nodes = []trieNode{{}}
+	t.labels = nil
+	t.children = nil
+	t.values = nil
+	t.build(0, runes, values, 0)
+	t.pending = nil
 }
 
-func dfs(trie *TrieNode, lookup map[string]string, s string) {
-	if trie.isWord {
-		lookup[s] = trie.value
+func (t *Trie) build(id int32, keys [][]rune, values [][]string, depth int) {
+	t.nodes[id].lo = int32(len(t.values))
+	// a key that ends at this node sorts before the longer ones
+	var i = 0
+	for ; i < len(keys) && len(keys[i]) == depth; i++ {
+		t.values = append(t.values, values[i]...)
 	}
-	for chr, t := range trie.Children {
-		var key = s + string(chr)
-		dfs(t, lookup, key)
+	var bounds []int
+	for j := i; j < len(keys); j++ {
+		if j == i || keys[j][depth] != keys[j-1][depth] {
+			bounds = append(bounds, j)
+		}
 	}
+	bounds = append(bounds, len(keys))
+	var edges = int32(len(t.labels))
+	t.nodes[id].edges = edges
+	t.nodes[id].nEdges = int32(len(bounds) - 1)
+	for n := 0; n < len(bounds)-1; n++ {
+		t.labels = append(t.labels, keys[bounds[n]][depth])
+		t.children = append(t.children, 0)
+	}
+	for n := 0; n < len(bounds)-1; n++ {
+		var child = int32(len(t.nodes))
+		t.nodes = append(t.nodes, trieNode{})
+		t.children[edges+int32(n)] = child
+		t.build(child, keys[bounds[n]:bounds[n+1]], values[bounds[n]:bounds[n+1]], depth+1)
+	}
+	t.nodes[id].hi = int32(len(t.values))
 }
 
-func FindPrefix(r *TrieNode, prefix string) map[string]string {
-	var currentNode = r
+// unbuild turns the compacted index back into pending keys, so that more keys can be inserted.
+func (t *Trie) unbuild() {
+	t.pending = map[string][]string{}
+	if len(t.nodes) > 0 {
+		t.collect(0, nil)
+	}
+	t.nodes, t.labels, t.children, t.values = nil, nil, nil, nil
+}
+
+func (t *Trie) collect(id int32, key []rune) {
+	var node = t.nodes[id]
+	var end = node.hi
+	if node.nEdges > 0 {
+		end = t.nodes[t.children[node.edges]].lo
+	}
+	if node.lo < end {
+		t.pending[string(key)] = append([]string{}, t.values[node.lo:end]...)
+	}
+	for n := node.edges; n < node.edges+node.nEdges; n++ {
+		t.collect(t.children[n], append(key, t.labels[n]))
+	}
+}
+
+func (t *Trie) find(prefix string) (int32, bool) {
+	t.Build()
+	if len(t.nodes) == 0 {
+		return 0, false
+	}
+	var id int32
 	for _, c := range prefix {
-		if cn := currentNode.Children[c]; cn != nil {
-			currentNode = cn
-		} else {
-			return nil
+		var node = t.nodes[id]
+		var labels = t.labels[node.edges : node.edges+node.nEdges]
+		var n = sort.Search(len(labels), func(i int) bool {
+			return labels[i] >= c
+		})
+		if n == len(labels) || labels[n] != c {
+			return 0, false
 		}
+		id = t.children[node.edges+int32(n)]
+	}
+	return id, true
+}
+
+// HasPrefix tells whether some key starts with prefix.
+func (t *Trie) HasPrefix(prefix string) bool {
+	_, ok := t.find(prefix)
+	return ok
+}
+
+// FindPrefix returns the values of the keys that start with prefix. The result is shared with
+// the index and must not be modified, though appending to it is safe.
+func (t *Trie) FindPrefix(prefix string) []string {
+	if id, ok := t.find(prefix); ok {
+		var node = t.nodes[id]
+		return t.values[node.lo:node.hi:node.hi]
 	}
-	var lookup = make(map[string]string)
-	dfs(currentNode, lookup, prefix)
-	return lookup
+	return nil
 }
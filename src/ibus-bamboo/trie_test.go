@@ -0,0 +1,84 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	var trie = NewTrie()
+	if !trie.IsEmpty() {
+		t.Errorf("A new trie, expected empty")
+	}
+	trie.Insert("smile", "😄")
+	trie.Insert("smiley", "😃")
+	trie.Insert("smile", "😊")
+	trie.Insert("smile", "😄")
+	trie.Insert("sad", "😢")
+	trie.Insert(":)", "🙂")
+	trie.Insert("cười", "😀")
+	trie.Insert("colon", ":")
+	trie.Build()
+	var tests = []struct {
+		prefix   string
+		expected []string
+	}{
+		{"smi", []string{"😄", "😊", "😃"}},
+		{"s", []string{"😢", "😄", "😊", "😃"}},
+		{"smiley", []string{"😃"}},
+		{"cườ", []string{"😀"}},
+		{":", []string{"🙂"}},
+		{"colon", []string{":"}},
+		{"x", nil},
+		{"smiles", nil},
+	}
+	for _, test := range tests {
+		if values := trie.FindPrefix(test.prefix); fmt.Sprint(values) != fmt.Sprint(test.expected) {
+			t.Errorf("Find prefix %s, expected %v, got %v", test.prefix, test.expected, values)
+		}
+	}
+	if !trie.HasPrefix("sm") || trie.HasPrefix("sx") {
+		t.Errorf("HasPrefix, expected sm but not sx")
+	}
+	if values := trie.FindPrefix(""); len(values) != 7 {
+		t.Errorf("Find the empty prefix, expected 7 values, got %v", values)
+	}
+	// appending to a result must not overwrite the index
+	_ = append(trie.FindPrefix("sa"), "x")
+	if values := trie.FindPrefix("sm"); values[0] != "😄" {
+		t.Errorf("Find prefix sm after appending to a result, got %v", values)
+	}
+	trie.Insert("sun", "☀️")
+	if values := trie.FindPrefix("s"); fmt.Sprint(values) != "[😢 😄 😊 😃 ☀️]" {
+		t.Errorf("Find prefix s after inserting into a built trie, got %v", values)
+	}
+}
+
+func BenchmarkTrieFindPrefix(b *testing.B) {
+	loadTestEmojis()
+	var query = "smiling face"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 1; j <= len(query); j++ {
+			emojiTable.trie.FindPrefix(query[:j])
+		}
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const unicodeMaxCandidates = 90
+
+type UnicodeNameTable struct {
+	chars []rune
+	names map[rune]string
+	words map[rune][]string
+}
+
+func NewUnicodeNameTable() *UnicodeNameTable {
+	return &UnicodeNameTable{
+		names: map[rune]string{},
+		words: map[rune][]string{},
+	}
+}
+
+func (t *UnicodeNameTable) IsEmpty() bool {
+	return len(t.chars) == 0
+}
+
+func (t *UnicodeNameTable) Name(r rune) string {
+	return t.names[r]
+}
+
+// loadUnicodeNameTable reads `code point;name` lines, e.g. "2192;RIGHTWARDS ARROW".
+func loadUnicodeNameTable(dataFile string) (*UnicodeNameTable, error) {
+	f, err := os.Open(dataFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var t = NewUnicodeNameTable()
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var parts = strings.SplitN(line, ";", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		code, err := strconv.ParseInt(parts[0], 16, 32)
+		if err != nil {
+			continue
+		}
+		var r = rune(code)
+		t.chars = append(t.chars, r)
+		t.names[r] = parts[1]
+		t.words[r] = strings.Fields(strings.ToLower(parts[1]))
+	}
+	return t, scanner.Err()
+}
+
+func loadUnicodeNames() {
+	var t, err = loadUnicodeNameTable(DictUnicodeNames)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	unicodeNames = t
+}
+
+// FindByName returns the characters whose names match every word of the query, the best matches
+// first. Among equally good matches, shorter names come first, then lower code points.
+func (t *UnicodeNameTable) FindByName(query string, limit int) []rune {
+	var tokens = fuzzyTokens(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+	var matches []rune
+	var scores = map[rune]int{}
+	var cache = map[string]int{}
+	for _, r := range t.chars {
+		if score := fuzzyScore(tokens, t.words[r], cache); score > 0 {
+			matches = append(matches, r)
+			scores[r] = score
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if scores[matches[i]] != scores[matches[j]] {
+			return scores[matches[i]] > scores[matches[j]]
+		}
+		return len(t.words[matches[i]]) < len(t.words[matches[j]])
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func isHexString(s string) bool {
+	for _, c := range s {
+		if !unicode.Is(unicode.ASCII_Hex_Digit, c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isDecimalString(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isInputtableRune(r rune) bool {
+	return r > 0 && utf8.ValidRune(r) && !unicode.IsControl(r)
+}
+
+// parseCodePointQuery reads the code points that a query may stand for: "u+2192", "0x2192"
+// and "2192" are hexadecimal, "#8594" and "&#8594;" are decimal. A bare number is read both
+// as hexadecimal and as decimal. A bare hexadecimal word such as "1f600" is read as well, see
+// isBareHexWord.
+func parseCodePointQuery(query string) []rune {
+	var q = strings.ToLower(strings.TrimSpace(query))
+	var hex, dec string
+	switch {
+	case strings.HasPrefix(q, "u+"):
+		hex = q[2:]
+	case strings.HasPrefix(q, "0x"):
+		hex = q[2:]
+	case strings.HasPrefix(q, "&#"):
+		dec = strings.TrimSuffix(q[2:], ";")
+	case strings.HasPrefix(q, "#"):
+		dec = q[1:]
+	case isDecimalString(q):
+		hex, dec = q, q
+	case len(q) >= 2 && isHexString(q):
+		hex = q
+	}
+	var codePoints []rune
+	if isHexString(hex) {
+		if code, err := strconv.ParseInt(hex, 16, 32); err == nil && isInputtableRune(rune(code)) {
+			codePoints = append(codePoints, rune(code))
+		}
+	}
+	if isDecimalString(dec) {
+		if code, err := strconv.ParseInt(dec, 10, 32); err == nil && isInputtableRune(rune(code)) {
+			if len(codePoints) == 0 || codePoints[0] != rune(code) {
+				codePoints = append(codePoints, rune(code))
+			}
+		}
+	}
+	return codePoints
+}
+
+// isBareHexWord tells if a query is hexadecimal without a prefix and with letters, like "1f600"
+// or the words "face" and "cafe". It stands for a code point only when it names no character.
+func isBareHexWord(query string) bool {
+	var q = strings.ToLower(strings.TrimSpace(query))
+	return len(q) >= 2 && isHexString(q) && !isDecimalString(q)
+}
+
+type UnicodeEngine struct {
+	keys       []rune
+	candidates []string
+	queried    bool
+}
+
+func NewUnicodeEngine() *UnicodeEngine {
+	return &UnicodeEngine{}
+}
+
+func (ue *UnicodeEngine) ProcessKey(key rune) {
+	ue.keys = append(ue.keys, key)
+	ue.queried = false
+}
+
+func (ue *UnicodeEngine) GetRawString() string {
+	return string(ue.keys)
+}
+
+func (ue *UnicodeEngine) Reset() {
+	ue.keys = nil
+	ue.queried = false
+}
+
+func (ue *UnicodeEngine) RemoveLastKey() {
+	if len(ue.keys) <= 0 {
+		return
+	}
+	ue.keys = ue.keys[:len(ue.keys)-1]
+	ue.queried = false
+}
+
+// Query returns the characters given by code point first, then the ones found by name.
+func (ue *UnicodeEngine) Query() []string {
+	if ue.queried {
+		return ue.candidates
+	}
+	var raw = string(ue.keys)
+	var seen = map[rune]bool{}
+	ue.candidates = nil
+	var names []rune
+	if !strings.HasPrefix(strings.ToLower(raw), "u+") {
+		names = unicodeNames.FindByName(raw, unicodeMaxCandidates)
+	}
+	var codePoints = parseCodePointQuery(raw)
+	if len(names) > 0 && isBareHexWord(raw) {
+		codePoints = nil
+	}
+	for _, r := range codePoints {
+		seen[r] = true
+		ue.candidates = append(ue.candidates, string(r))
+	}
+	for _, r := range names {
+		if !seen[r] {
+			ue.candidates = append(ue.candidates, string(r))
+		}
+	}
+	ue.queried = true
+	return ue.candidates
+}
+
+// Describe formats a candidate as its code point and name, e.g. "U+2192 RIGHTWARDS ARROW".
+func (ue *UnicodeEngine) Describe(s string) string {
+	var r, _ = utf8.DecodeRuneInString(s)
+	if name := unicodeNames.Name(r); name != "" {
+		return fmt.Sprintf("U+%04X %s", r, name)
+	}
+	return fmt.Sprintf("U+%04X", r)
+}
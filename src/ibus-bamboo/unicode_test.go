@@ -0,0 +1,113 @@
+/*
+ * Bamboo - A Vietnamese Input method editor
+ * Copyright (C) 2018 Luong Thanh Lam <ltlam93@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func loadTestUnicodeNames() {
+	unicodeNames, _ = loadUnicodeNameTable("../../" + DictUnicodeNames)
+}
+
+func TestParseCodePointQuery(t *testing.T) {
+	var tests = []struct {
+		query    string
+		expected []rune
+	}{
+		{"u+2192", []rune{'→'}},
+		{"U+2264", []rune{'≤'}},
+		{"0x2030", []rune{'‰'}},
+		{"1F600", []rune{'😀'}},
+		{"#8594", []rune{'→'}},
+		{"&#8594;", []rune{'→'}},
+		{"8594", []rune{0x8594, '→'}},
+		{"41", []rune{'A', ')'}},
+		{"u+d800", nil},
+		{"u+110000", nil},
+		{"u+0000", nil},
+		{"arrow", nil},
+		{"a", nil},
+	}
+	for _, test := range tests {
+		if cps := parseCodePointQuery(test.query); fmt.Sprint(cps) != fmt.Sprint(test.expected) {
+			t.Errorf("Parse code point query %s, expected %q, got %q", test.query, test.expected, cps)
+		}
+	}
+}
+
+func TestFindUnicodeByName(t *testing.T) {
+	loadTestUnicodeNames()
+	var tests = []struct {
+		query    string
+		expected rune
+	}{
+		{"rightwards arrow", '→'},
+		{"less-than or equal to", '≤'},
+		{"per mille", '‰'},
+		{"greek small letter alpha", 'α'},
+		{"em dash", '—'},
+		{"rigthwards arrow", '→'},
+	}
+	for _, test := range tests {
+		var cps = unicodeNames.FindByName(test.query, unicodeMaxCandidates)
+		if len(cps) == 0 || cps[0] != test.expected {
+			t.Errorf("Find unicode character `%s`, expected %c first, got %q", test.query, test.expected, cps)
+		}
+	}
+	if cps := unicodeNames.FindByName("arrow", 5); len(cps) != 5 {
+		t.Errorf("Find unicode character `arrow`, expected 5 results, got %d", len(cps))
+	}
+}
+
+func TestUnicodeEngine(t *testing.T) {
+	loadTestUnicodeNames()
+	var ue = NewUnicodeEngine()
+	for _, key := range "u+2192" {
+		ue.ProcessKey(key)
+	}
+	if cs := ue.Query(); len(cs) != 1 || cs[0] != "→" {
+		t.Errorf("Query u+2192, expected [→], got %v", cs)
+	}
+	if s := ue.Describe("→"); s != "U+2192 RIGHTWARDS ARROW" {
+		t.Errorf("Describe →, expected `U+2192 RIGHTWARDS ARROW`, got %s", s)
+	}
+	ue.Reset()
+	for _, key := range "face" {
+		ue.ProcessKey(key)
+	}
+	// a word is looked up by name, not read as the code point U+FACE
+	var cs = ue.Query()
+	if len(cs) == 0 || inStringList(cs, "\uFACE") {
+		t.Errorf("Query face, expected the characters named face without U+FACE, got %v", cs)
+	}
+	ue.RemoveLastKey()
+	if ue.GetRawString() != "fac" {
+		t.Errorf("Remove the last key, expected fac, got %s", ue.GetRawString())
+	}
+	// a hexadecimal word which names no character is a code point
+	ue.Reset()
+	for _, key := range "1f600" {
+		ue.ProcessKey(key)
+	}
+	if cs := ue.Query(); len(cs) == 0 || cs[0] != "😀" {
+		t.Errorf("Query 1f600, expected [😀], got %v", cs)
+	}
+}
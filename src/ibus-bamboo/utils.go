@@ -20,7 +20,6 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -46,13 +45,25 @@ const (
 
 	DataDir          = "/usr/share/ibus-bamboo"
 	DictVietnameseCm = "data/vietnamese.cm.dict"
-	DictEmojiOne     = "data/emojione.json"
+	DictVnBigrams    = "data/vietnamese.bigram.txt"
+	DictEnglish      = "data/english.dict"
+	DictEmojiTest    = "data/emoji-test.txt"
+	DictEmoticons    = "data/emoticons.txt"
+	DictUnicodeNames = "data/unicode-names.txt"
 )
 
+var DictEmojiAnnotations = []string{
+	"data/annotations/en.xml",
+	"data/annotations/vi.xml",
+}
+
 const (
 	configDir        = "%s/.config/ibus-%s"
 	configFile       = "%s/ibus-%s.config.json"
 	mactabFile       = "%s/ibus-%s.macro.text"
+	emojiHistoryFile = "%s/ibus-%s.emoji.history.json"
+	personalDictFile = "%s/ibus-%s.dict.txt"
+	dictLearningFile = "%s/ibus-%s.dict.learning"
 	sampleMactabFile = "data/macro.tpl.txt"
 )
 
@@ -86,10 +97,24 @@ const (
 	IBimQuickSwitchEnabled
 	IBrestoreKeyStrokesEnabled
 	IBmouseCapturing
+	IBunicodePickerEnabled
+	IBautoCompleteEnabled
+	IBenglishDictEnabled
+	IBspellDiagnosisEnabled
+	IBtypingHintEnabled
+	IBresumeComposition
 	IBstdFlags = IBspellCheckEnabled | IBspellCheckWithRules | IBautoNonVnRestore | IBddFreeStyle |
 		IBemojiDisabled | IBinputModeLookupTableEnabled | IBmouseCapturing | IBautoCapitalizeMacro
 )
 
+// how eagerly an English word is kept instead of its Vietnamese reading, the zero value of a
+// config is normal
+const (
+	EnglishBiasLow    = iota - 1 // same as normal, but only for words of 4 letters or more
+	EnglishBiasNormal            // when the Vietnamese reading is not in the dictionary
+	EnglishBiasHigh              // always
+)
+
 const (
 	JemojiEnabled uint = 1 << iota
 	JmacroEnabled
@@ -130,6 +155,13 @@ type Config struct {
 	JupiterFlags           uint
 	DefaultInputMode       int
 	InputModeMapping       map[string]int
+	EmojiSkinTone          int
+	EnglishBias            int
+	DictionaryFiles        []string          // extra dictionaries, e.g. shared by a team; the personal dictionary comes last
+	SpellingFiles          map[string]string // input method => spelling rules over the Vietnamese ones
+
+	// the input methods of the files in the config dir, see loadInputMethodFiles
+	InputMethodFiles map[string]bamboo.InputMethodDefinition `json:"-"`
 }
 
 func getConfigDir(ngName string) string {
@@ -146,10 +178,40 @@ func setupConfigDir(ngName string) {
 	}
 }
 
+// newPreeditor creates the bamboo engine of the input method, with its spelling rules if it has a
+// spelling file; a relative path is in the config dir.
+func newPreeditor(c *Config, engineName string) bamboo.IEngine {
+	var inputMethod = bamboo.ParseInputMethod(c.getInputMethodDefinitions(), c.InputMethod)
+	if path := c.SpellingFiles[c.InputMethod]; path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(getConfigDir(engineName), path)
+		}
+		if spelling, err := loadSpelling(path); err == nil {
+			inputMethod.Spelling = spelling
+		} else {
+			log.Println(err)
+		}
+	}
+	return bamboo.NewEngine(inputMethod, c.Flags)
+}
+
+func loadSpelling(path string) (*bamboo.Spelling, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return bamboo.ParseSpelling(f, bamboo.DefaultSpelling)
+}
+
 func getConfigPath(engineName string) string {
 	return fmt.Sprintf(configFile, getConfigDir(engineName), engineName)
 }
 
+func getEmojiHistoryPath(engineName string) string {
+	return fmt.Sprintf(emojiHistoryFile, getConfigDir(engineName), engineName)
+}
+
 func loadConfig(engineName string) *Config {
 	var flags = IBstdFlags
 	if isGnome {
@@ -169,11 +231,37 @@ func loadConfig(engineName string) *Config {
 	data, err := ioutil.ReadFile(getConfigPath(engineName))
 	if err == nil {
 		json.Unmarshal(data, &c)
+		addReplacingEffects(c.InputMethodDefinitions)
 	}
+	c.InputMethodFiles = loadInputMethodFiles(engineName)
 
 	return &c
 }
 
+// addReplacingEffects gives the built-in input methods of an older config the consonant clusters
+// of quick telex (CC>CH, ^F>PH, G$>NG), a saved definition replaces the built-in one as a whole
+func addReplacingEffects(definitions map[string]bamboo.InputMethodDefinition) {
+	for name, builtin := range bamboo.GetInputMethodDefinitions() {
+		var def = definitions[name]
+		if def == nil {
+			continue
+		}
+		for key, line := range builtin {
+			var effects = strings.Split(def[key], ",")
+			for _, effect := range strings.Split(line, ",") {
+				if !strings.Contains(effect, ">") || inStringList(effects, effect) {
+					continue
+				}
+				if def[key] == "" {
+					def[key] = effect
+				} else {
+					def[key] += "," + effect
+				}
+			}
+		}
+	}
+}
+
 func saveConfig(c *Config, engineName string) {
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
@@ -197,6 +285,21 @@ func getEngineSubFile(fileName string) string {
 	return filepath.Join(filepath.Dir(os.Args[0]), fileName)
 }
 
+// expandMacro returns the text of a macro, with autoCapitalize in the case of its key, e.g. BTW is
+// expanded to BY THE WAY
+func expandMacro(text, key string, autoCapitalize bool) string {
+	if text == "" || !autoCapitalize {
+		return text
+	}
+	switch determineMacroCase(key) {
+	case VnCaseAllSmall:
+		return strings.ToLower(text)
+	case VnCaseAllCapital:
+		return strings.ToUpper(text)
+	}
+	return text
+}
+
 func determineMacroCase(str string) uint8 {
 	var chars = []rune(str)
 	if unicode.IsLower(chars[0]) {
@@ -284,29 +387,9 @@ func sortStrings(list []string) []string {
 	return strList
 }
 
-func loadDictionary(dataFiles ...string) (map[string]bool, error) {
-	var data = map[string]bool{}
-	for _, dataFile := range dataFiles {
-		f, err := os.Open(dataFile)
-		if err != nil {
-			return nil, err
-		}
-		rd := bufio.NewReader(f)
-		for {
-			line, _, err := rd.ReadLine()
-			if err != nil {
-				break
-			}
-			if len(line) == 0 {
-				continue
-			}
-			var tmp = []byte(strings.ToLower(string(line)))
-			data[string(tmp)] = true
-			//bamboo.AddTrie(rootWordTrie, []rune(string(line)), false)
-		}
-		f.Close()
-	}
-	return data, nil
+// removeVietnameseAccents returns the lower case, tone-less and mark-less form of a word, e.g. cười -> cuoi
+func removeVietnameseAccents(word string) string {
+	return dictionaryKey(word, bamboo.ToneLess|bamboo.MarkLess)
 }
 
 func isMovementKey(keyVal uint32) bool {
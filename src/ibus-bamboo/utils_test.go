@@ -1,8 +1,13 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
+
+	"github.com/BambooEngine/bamboo-core"
 )
 
 func TestSortStringList(t *testing.T) {
@@ -18,3 +23,51 @@ func TestSortStringList(t *testing.T) {
 		t.Errorf("Sorting strings, expected %s, got %s", "ca", data[0])
 	}
 }
+
+func TestNewPreeditorWithSpelling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ibus-bamboo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "loanwords.spelling")
+	ioutil.WriteFile(path, []byte("onset foreign = f\nonset+vowel foreign = 2\n"), 0644)
+	var c = &Config{
+		InputMethod:            "VNI",
+		InputMethodDefinitions: bamboo.GetInputMethodDefinitions(),
+		Flags:                  bamboo.EstdFlags,
+		SpellingFiles:          map[string]string{"VNI": path},
+	}
+	var ng = newPreeditor(c, "bamboo")
+	ng.ProcessString("fo", bamboo.VietnameseMode)
+	if !ng.IsValid(true) {
+		t.Errorf("Check [fo] with the spelling file, got %s", ng.(bamboo.ISyllableChecker).Diagnose(true))
+	}
+	c.InputMethod = "Telex"
+	ng = newPreeditor(c, "bamboo")
+	ng.ProcessString("fo", bamboo.EnglishMode)
+	if ng.IsValid(true) {
+		t.Errorf("Check [fo] without the spelling file, expected it to be invalid")
+	}
+}
+
+func TestAddReplacingEffects(t *testing.T) {
+	// a config saved before the quick telex clusters were part of the definitions
+	var definitions = map[string]bamboo.InputMethodDefinition{
+		"Telex": {"f": "DauHuyen", "c": "", "w": "UOA_ƯƠĂ"},
+		"VNI":   {"1": "DauSac"},
+		"Mine":  {"f": "DauHuyen"},
+	}
+	addReplacingEffects(definitions)
+	var telex = definitions["Telex"]
+	if telex["f"] != "DauHuyen,^F>PH" || telex["c"] != "CC>CH" || telex["g"] != "GG>GI,G$>NG" || telex["w"] != "UOA_ƯƠĂ,^W>QU" {
+		t.Errorf("Add the replacing effects to Telex, got %v", telex)
+	}
+	if len(definitions["VNI"]) != 1 || definitions["Mine"]["f"] != "DauHuyen" {
+		t.Errorf("Add the replacing effects to other input methods, got %v", definitions)
+	}
+	addReplacingEffects(definitions)
+	if telex["f"] != "DauHuyen,^F>PH" {
+		t.Errorf("Add the replacing effects twice, got %s", telex["f"])
+	}
+}